@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+const bitbucketURL = "https://bitbucket.org/"
+
+// loadBitbucketEnv fills the repository, commit and build information from
+// the Bitbucket Pipelines default variables. The status is only known in an
+// after-script, where BITBUCKET_EXIT_CODE is set.
+func (p *Plugin) loadBitbucketEnv(getenv func(string) string) {
+	setString(&p.Repo.FullName, getenv("BITBUCKET_REPO_FULL_NAME"))
+	setString(&p.Repo.Namespace, getenv("BITBUCKET_WORKSPACE"))
+	setString(&p.Repo.Name, getenv("BITBUCKET_REPO_SLUG"))
+	repoURL := bitbucketURL + p.Repo.FullName
+	if origin := getenv("BITBUCKET_GIT_HTTP_ORIGIN"); len(origin) > 0 {
+		repoURL = "https://" + strings.TrimPrefix(strings.TrimPrefix(origin, "http://"), "https://")
+	}
+
+	setString(&p.Commit.Sha, getenv("BITBUCKET_COMMIT"))
+	setString(&p.Commit.Branch, getenv("BITBUCKET_PR_DESTINATION_BRANCH"), getenv("BITBUCKET_BRANCH"))
+	if len(p.Commit.Sha) > 0 {
+		p.Commit.Link = repoURL + "/commits/" + p.Commit.Sha
+	}
+
+	if number, err := strconv.Atoi(getenv("BITBUCKET_BUILD_NUMBER")); err == nil {
+		p.Build.Number = number
+		p.Build.Link = bitbucketURL + p.Repo.FullName + "/pipelines/results/" + strconv.Itoa(number)
+	}
+	setString(&p.Build.Tag, getenv("BITBUCKET_TAG"))
+	setString(&p.Build.PR, getenv("BITBUCKET_PR_ID"))
+	setString(&p.Build.DeployTo, getenv("BITBUCKET_DEPLOYMENT_ENVIRONMENT"))
+
+	switch code := getenv("BITBUCKET_EXIT_CODE"); code {
+	case "":
+	case "0":
+		p.Build.Status = "success"
+	default:
+		p.Build.Status = "failure"
+	}
+
+	switch {
+	case len(p.Build.Tag) > 0:
+		p.Build.Event = "tag"
+	case len(p.Build.PR) > 0:
+		p.Build.Event = "pull_request"
+		p.Commit.Link = repoURL + "/pull-requests/" + p.Build.PR
+	}
+}
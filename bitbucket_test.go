@@ -0,0 +1,76 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadBitbucketEnv(t *testing.T) {
+	plugin := Plugin{
+		Build:  Build{Event: "push"},
+		Config: Config{Provider: providerBitbucket},
+	}
+
+	plugin.loadProviderEnv(mapEnv(map[string]string{
+		"BITBUCKET_BUILD_NUMBER":          "42",
+		"BITBUCKET_REPO_FULL_NAME":        "appleboy/go-hello",
+		"BITBUCKET_WORKSPACE":             "appleboy",
+		"BITBUCKET_REPO_SLUG":             "go-hello",
+		"BITBUCKET_GIT_HTTP_ORIGIN":       "http://bitbucket.org/appleboy/go-hello",
+		"BITBUCKET_COMMIT":                "e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2",
+		"BITBUCKET_BRANCH":                "feature/topics",
+		"BITBUCKET_PR_ID":                 "8",
+		"BITBUCKET_PR_DESTINATION_BRANCH": "master",
+		"BITBUCKET_EXIT_CODE":             "1",
+		"GIT_AUTHOR_NAME":                 "appleboy",
+		"GIT_COMMIT_MESSAGE":              "feat: support forum topics",
+	}))
+
+	assert.Equal(t, "appleboy/go-hello", plugin.Repo.FullName)
+	assert.Equal(t, "master", plugin.Commit.Branch)
+	assert.Equal(t, "failure", plugin.Build.Status)
+	assert.Equal(t, "pull_request", plugin.Build.Event)
+	assert.Equal(
+		t,
+		[]string{
+			"❌ Pipeline #42 of `appleboy/go-hello` failure.\n\n🔀 PR #8 by appleboy into `master`:\n``` feat: support forum topics ```\n\n🔗 https://bitbucket.org/appleboy/go-hello/pull-requests/8\n🌐 https://bitbucket.org/appleboy/go-hello/pipelines/results/42",
+		},
+		plugin.Message(),
+	)
+}
+
+func TestLoadBitbucketEnvStatus(t *testing.T) {
+	tests := map[string]string{
+		"":  "running",
+		"0": "success",
+		"2": "failure",
+	}
+
+	for code, want := range tests {
+		plugin := Plugin{Build: Build{Status: "running"}}
+		plugin.loadBitbucketEnv(mapEnv(map[string]string{
+			"BITBUCKET_REPO_FULL_NAME": "appleboy/go-hello",
+			"BITBUCKET_COMMIT":         "e7c4f0a",
+			"BITBUCKET_TAG":            "v1.0.0",
+			"BITBUCKET_EXIT_CODE":      code,
+		}))
+		assert.Equal(t, want, plugin.Build.Status, code)
+		assert.Equal(t, "tag", plugin.Build.Event)
+		assert.Equal(t, "https://bitbucket.org/appleboy/go-hello/commits/e7c4f0a", plugin.Commit.Link)
+	}
+}
+
+func TestLoadBitbucketEnvNoAuthor(t *testing.T) {
+	// git knows no such commit, and the account of the step is only a UUID
+	plugin := Plugin{Config: Config{Provider: providerBitbucket}}
+	plugin.loadProviderEnv(mapEnv(map[string]string{
+		"BITBUCKET_REPO_FULL_NAME":      "appleboy/go-hello",
+		"BITBUCKET_COMMIT":              "0000000000000000000000000000000000000000",
+		"BITBUCKET_STEP_TRIGGERER_UUID": "{4f3c2a1b-0000-4000-8000-000000000000}",
+	}))
+
+	assert.Empty(t, plugin.Commit.Author)
+	assert.Empty(t, plugin.Commit.Message)
+	assert.Error(t, plugin.gitErr)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/urfave/cli"
+)
+
+var chatsCommand = cli.Command{
+	Name:   "chats",
+	Usage:  "list the chats, forum topics and users that recently sent updates to the bot",
+	Action: chatsAction,
+}
+
+type (
+	// chatInfo is a chat the bot received updates from.
+	chatInfo struct {
+		ID     int64
+		Type   string
+		Title  string
+		Topics []topicInfo
+	}
+
+	// topicInfo is a forum topic of a chat.
+	topicInfo struct {
+		ID   int
+		Name string
+	}
+
+	// userInfo is a user who talked to the bot.
+	userInfo struct {
+		ID       int64
+		UserName string
+		Name     string
+	}
+)
+
+// chatsAction prints the chats found in the pending updates of the bot.
+// The updates are not confirmed, so other tools still receive them.
+func chatsAction(c *cli.Context) error {
+	plugin := Plugin{
+		Config: Config{
+			Token:         c.GlobalString("token"),
+			TokenFile:     c.GlobalString("token.file"),
+			Debug:         c.GlobalBool("debug"),
+			LogFormat:     c.GlobalString("log.format"),
+			LogLevel:      c.GlobalString("log.level"),
+			TraceHTTP:     c.GlobalBool("trace.http"),
+			Proxy:         c.GlobalString("proxy"),
+			CACert:        c.GlobalString("ca.cert"),
+			ClientCert:    c.GlobalString("client.cert"),
+			ClientKey:     c.GlobalString("client.key"),
+			TLSMinVersion: c.GlobalString("tls.min.version"),
+			Socks5:        c.GlobalString("socks5"),
+			APIURL:        c.GlobalString("api.url"),
+			Timeout:       c.GlobalDuration("timeout"),
+		},
+	}
+
+	if err := plugin.loadTokenFile(); err != nil {
+		return err
+	}
+
+	if len(plugin.Config.Token) == 0 {
+		return errors.New("missing telegram token")
+	}
+
+	bot, err := plugin.newBot(context.Background())
+	if err != nil {
+		return err
+	}
+
+	updates, err := bot.GetUpdates(tgbotapi.UpdateConfig{
+		Limit: 100,
+		AllowedUpdates: []string{
+			"message", "edited_message", "channel_post", "edited_channel_post", "my_chat_member",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to get updates: %w", plugin.apiError(err))
+	}
+
+	chats, users := collectChats(updates)
+	if len(chats) == 0 {
+		fmt.Fprintln(c.App.Writer, "No recent updates. Send a message to the bot or add it to a group, then try again.")
+		return nil
+	}
+
+	return printChats(c.App.Writer, chats, users)
+}
+
+// collectChats returns the chats, forum topics and users of the updates in
+// the order they first appear.
+func collectChats(updates []tgbotapi.Update) ([]chatInfo, []userInfo) {
+	var chats []chatInfo
+	var users []userInfo
+	chatIndex := map[int64]int{}
+	userIndex := map[int64]bool{}
+
+	addChat := func(chat tgbotapi.Chat) *chatInfo {
+		if i, ok := chatIndex[chat.ID]; ok {
+			return &chats[i]
+		}
+		chatIndex[chat.ID] = len(chats)
+		chats = append(chats, chatInfo{ID: chat.ID, Type: chat.Type, Title: chatTitle(chat)})
+		return &chats[len(chats)-1]
+	}
+
+	addUser := func(user *tgbotapi.User) {
+		if user == nil || user.IsBot || userIndex[user.ID] {
+			return
+		}
+		userIndex[user.ID] = true
+		users = append(users, userInfo{
+			ID:       user.ID,
+			UserName: user.UserName,
+			Name:     strings.TrimSpace(user.FirstName + " " + user.LastName),
+		})
+	}
+
+	for _, update := range updates {
+		if member := update.MyChatMember; member != nil {
+			addChat(member.Chat)
+			addUser(&member.From)
+		}
+
+		for _, message := range []*tgbotapi.Message{
+			update.Message, update.EditedMessage, update.ChannelPost, update.EditedChannelPost,
+		} {
+			if message == nil {
+				continue
+			}
+			chat := addChat(message.Chat)
+			addUser(message.From)
+			if message.IsTopicMessage && message.MessageThreadID > 0 {
+				chat.addTopic(message.MessageThreadID, topicName(message))
+			}
+		}
+	}
+
+	return chats, users
+}
+
+// addTopic adds a forum topic once, filling in its name when it is learned
+// from a later message.
+func (c *chatInfo) addTopic(id int, name string) {
+	for i := range c.Topics {
+		if c.Topics[i].ID == id {
+			setString(&c.Topics[i].Name, c.Topics[i].Name, name)
+			return
+		}
+	}
+	c.Topics = append(c.Topics, topicInfo{ID: id, Name: name})
+}
+
+// topicName returns the forum topic name of a message, only known when the
+// message created the topic or replies to its creation.
+func topicName(message *tgbotapi.Message) string {
+	if message.ForumTopicCreated != nil {
+		return message.ForumTopicCreated.Name
+	}
+	if reply := message.ReplyToMessage; reply != nil && reply.ForumTopicCreated != nil {
+		return reply.ForumTopicCreated.Name
+	}
+
+	return ""
+}
+
+func chatTitle(chat tgbotapi.Chat) string {
+	switch {
+	case len(chat.Title) > 0:
+		return chat.Title
+	case len(chat.UserName) > 0:
+		return "@" + chat.UserName
+	default:
+		return strings.TrimSpace(chat.FirstName + " " + chat.LastName)
+	}
+}
+
+// printChats writes the chats with their topics and the users as tables.
+func printChats(w io.Writer, chats []chatInfo, users []userInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "CHAT ID\tTYPE\tTITLE")
+	for _, chat := range chats {
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", chat.ID, chat.Type, chat.Title)
+		for _, topic := range chat.Topics {
+			fmt.Fprintf(tw, "  topic %d\t\t%s\n", topic.ID, topic.Name)
+		}
+	}
+
+	if len(users) > 0 {
+		fmt.Fprintln(tw)
+		fmt.Fprintln(tw, "USER ID\tUSERNAME\tNAME")
+		for _, user := range users {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", user.ID, user.UserName, user.Name)
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+const testBot = `{"id": 999, "is_bot": true, "first_name": "drone", "username": "drone_bot"}`
+
+const testUpdates = `[
+	{"update_id": 1, "my_chat_member": {
+		"chat": {"id": -1001234567890, "type": "supergroup", "title": "Drone CI"},
+		"from": {"id": 111, "first_name": "Bo-Yi", "last_name": "Wu", "username": "appleboy"},
+		"date": 1700000000
+	}},
+	{"update_id": 2, "message": {
+		"message_id": 10, "message_thread_id": 7, "is_topic_message": true,
+		"chat": {"id": -1001234567890, "type": "supergroup", "title": "Drone CI", "is_forum": true},
+		"from": {"id": 222, "first_name": "Jane"},
+		"date": 1700000001, "text": "hello"
+	}},
+	{"update_id": 3, "message": {
+		"message_id": 11, "message_thread_id": 7, "is_topic_message": true,
+		"chat": {"id": -1001234567890, "type": "supergroup", "title": "Drone CI", "is_forum": true},
+		"from": {"id": 111, "first_name": "Bo-Yi", "last_name": "Wu", "username": "appleboy"},
+		"reply_to_message": {"message_id": 7, "date": 1700000000,
+			"chat": {"id": -1001234567890, "type": "supergroup"},
+			"forum_topic_created": {"name": "Deploys", "icon_color": 7322096}},
+		"date": 1700000002, "text": "deployed"
+	}},
+	{"update_id": 4, "message": {
+		"message_id": 1,
+		"chat": {"id": 111, "type": "private", "first_name": "Bo-Yi", "last_name": "Wu", "username": "appleboy"},
+		"from": {"id": 111, "first_name": "Bo-Yi", "last_name": "Wu", "username": "appleboy"},
+		"date": 1700000003, "text": "/start"
+	}},
+	{"update_id": 5, "channel_post": {
+		"message_id": 3,
+		"chat": {"id": -1009876543210, "type": "channel", "title": "Releases"},
+		"date": 1700000004, "text": "v1.0.0"
+	}}
+]`
+
+func TestChatsCommand(t *testing.T) {
+	server := newBotServer(t, map[string]string{
+		"getMe":      botResult(testBot),
+		"getUpdates": botResult(testUpdates),
+	})
+
+	var buf bytes.Buffer
+	app := cli.NewApp()
+	app.Writer = &buf
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "token"},
+		cli.StringFlag{Name: "api.url"},
+		cli.StringFlag{Name: "socks5"},
+		cli.BoolFlag{Name: "debug"},
+	}
+	app.Commands = []cli.Command{chatsCommand}
+
+	require.NoError(t, app.Run([]string{"telegram", "--token", "123456:ABC", "--api.url", server.URL, "chats"}))
+	assert.Equal(
+		t,
+		"CHAT ID         TYPE        TITLE\n"+
+			"-1001234567890  supergroup  Drone CI\n"+
+			"  topic 7                   Deploys\n"+
+			"111             private     @appleboy\n"+
+			"-1009876543210  channel     Releases\n"+
+			"\n"+
+			"USER ID  USERNAME  NAME\n"+
+			"111      appleboy  Bo-Yi Wu\n"+
+			"222                Jane\n",
+		buf.String(),
+	)
+
+	err := app.Run([]string{"telegram", "chats"})
+	assert.EqualError(t, err, "missing telegram token")
+}
+
+func TestChatsCommandError(t *testing.T) {
+	server := newBotServer(t, map[string]string{
+		"getMe": botResult(testBot),
+	})
+
+	app := cli.NewApp()
+	app.Writer = &bytes.Buffer{}
+	app.Flags = []cli.Flag{cli.StringFlag{Name: "token"}, cli.StringFlag{Name: "api.url"}}
+	app.Commands = []cli.Command{chatsCommand}
+
+	err := app.Run([]string{"telegram", "--token", "123456:ABC", "--api.url", server.URL + "/bot%s/%s", "chats"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "123456:ABC")
+}
+
+func TestCollectChatsEmpty(t *testing.T) {
+	chats, users := collectChats([]tgbotapi.Update{{UpdateID: 1}})
+	assert.Empty(t, chats)
+	assert.Empty(t, users)
+}
+
+func TestAPIEndpoint(t *testing.T) {
+	assert.Equal(t, "http://localhost:8081/bot%s/%s", apiEndpoint("http://localhost:8081/"))
+	assert.Equal(t, "http://localhost:8081/custom/bot%s/%s", apiEndpoint("http://localhost:8081/custom/bot%s/%s"))
+}
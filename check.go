@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/urfave/cli"
+)
+
+var checkCommand = cli.Command{
+	Name:   "check",
+	Usage:  "check the token, recipients and permissions without sending a message",
+	Action: checkAction,
+}
+
+// chatCheck is the result of checking a recipient.
+type chatCheck struct {
+	ChatID  int64
+	Type    string
+	Title   string
+	CanPost bool
+	IsForum bool
+	Thread  string
+	Problem error
+}
+
+// checkAction validates the settings of the plugin and prints a report for
+// every recipient.
+func checkAction(c *cli.Context) error {
+	plugin, err := newPlugin(c.Parent())
+	if err != nil {
+		return err
+	}
+
+	return forEachProfile(c.Parent(), plugin, func(p *Plugin) error {
+		return p.check(context.Background(), c.App.Writer)
+	})
+}
+
+// check prints the bot and the checks of every recipient.
+func (p *Plugin) check(ctx context.Context, w io.Writer) error {
+	if err := p.loadTokenFile(); err != nil {
+		return err
+	}
+
+	if len(p.Config.Token) == 0 || len(p.Config.To) == 0 {
+		return errors.New("missing telegram token or user list")
+	}
+
+	bot, err := p.newBot(ctx)
+	if err != nil {
+		return fmt.Errorf("invalid telegram token: %w", err)
+	}
+	fmt.Fprintf(w, "Bot @%s (%d)\n\n", bot.Self.UserName, bot.Self.ID)
+
+	checks := p.checkChats(ctx, bot)
+	if err := printChecks(w, checks); err != nil {
+		return err
+	}
+
+	return checkError(checks)
+}
+
+// checkChats checks every recipient of the message.
+func (p *Plugin) checkChats(ctx context.Context, bot *tgbotapi.BotAPI) []chatCheck {
+	ids := parseTo(p.Config.To, p.Commit.Email, p.Config.MatchEmail)
+	checks := make([]chatCheck, 0, len(ids))
+	for _, id := range ids {
+		checks = append(checks, p.checkChat(ctx, bot, id))
+	}
+
+	return checks
+}
+
+// checkChat checks that the bot can post to a chat and, when a message
+// thread is set, that the chat is a forum with this topic. The topic is
+// probed with a typing action, the only request that accepts a thread id
+// without sending anything. A group upgraded to a supergroup is recorded
+// for Send, and the supergroup is checked instead.
+func (p *Plugin) checkChat(ctx context.Context, bot *tgbotapi.BotAPI, id int64) chatCheck {
+	check := chatCheck{ChatID: id}
+	chatConfig := tgbotapi.ChatConfig{ChatID: id}
+
+	chat, err := bot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: chatConfig})
+	if to := migration(err); to != 0 && to != id {
+		p.migrate(ctx, id, to)
+		return p.checkChat(ctx, bot, to)
+	}
+	if err != nil {
+		check.Problem = p.apiError(err)
+		return check
+	}
+	check.Type, check.Title, check.IsForum = chat.Type, chatTitle(chat.Chat), chat.IsForum
+
+	// the bot can always answer a user who started it
+	check.CanPost = chat.IsPrivate()
+	if !check.CanPost {
+		member, err := bot.GetChatMember(tgbotapi.GetChatMemberConfig{
+			ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatConfig: chatConfig, UserID: bot.Self.ID},
+		})
+		if err != nil {
+			check.Problem = p.apiError(err)
+			return check
+		}
+		if check.CanPost = canPost(chat, member); !check.CanPost {
+			check.Problem = &classifiedError{
+				class: ErrForbidden,
+				err:   fmt.Errorf("bot cannot post messages (status %s)", member.Status),
+			}
+			return check
+		}
+	}
+
+	if p.Config.MessageThreadID == 0 {
+		return check
+	}
+
+	if !chat.IsForum {
+		check.Thread = "no forum"
+		check.Problem = &classifiedError{
+			class: ErrBadRequest,
+			err:   fmt.Errorf("message thread %d is set but the chat is not a forum", p.Config.MessageThreadID),
+		}
+		return check
+	}
+
+	action := tgbotapi.NewChatAction(id, tgbotapi.ChatTyping)
+	action.MessageThreadID = p.Config.MessageThreadID
+	if _, err := bot.Request(action); err != nil {
+		check.Thread = "missing"
+		check.Problem = fmt.Errorf("message thread %d: %w", p.Config.MessageThreadID, p.apiError(err))
+		return check
+	}
+	check.Thread = "ok"
+
+	return check
+}
+
+// canPost reports whether a chat member may send messages to the chat.
+func canPost(chat tgbotapi.ChatFullInfo, member tgbotapi.ChatMember) bool {
+	switch member.Status {
+	case "creator":
+		return true
+	case "administrator":
+		return !chat.IsChannel() || member.CanPostMessages
+	case "member":
+		return !chat.IsChannel() && (chat.Permissions == nil || chat.Permissions.CanSendMessages)
+	case "restricted":
+		return member.CanSendMessages
+	default:
+		// left or kicked
+		return false
+	}
+}
+
+// checkError joins the problems of all checks, nil when there are none.
+func checkError(checks []chatCheck) error {
+	var errs []error
+	for _, check := range checks {
+		if check.Problem != nil {
+			errs = append(errs, fmt.Errorf("chat %d: %w", check.ChatID, check.Problem))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// printChecks writes the result of every check as a table.
+func printChecks(w io.Writer, checks []chatCheck) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "CHAT ID\tTYPE\tTITLE\tCAN POST\tFORUM\tTHREAD\tPROBLEM")
+	for _, check := range checks {
+		var problem string
+		if check.Problem != nil {
+			problem = check.Problem.Error()
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			check.ChatID,
+			check.Type,
+			check.Title,
+			yesNo(check.CanPost),
+			yesNo(check.IsForum),
+			check.Thread,
+			problem,
+		)
+	}
+
+	return tw.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
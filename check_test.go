@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func newCheckServer(t *testing.T) string {
+	t.Helper()
+
+	server := newBotServer(t, map[string]string{
+		"getMe": botResult(testBot),
+
+		"getChat:111":                  botResult(`{"id": 111, "type": "private", "username": "appleboy"}`),
+		"getChat:-1001234567890":       botResult(`{"id": -1001234567890, "type": "supergroup", "title": "Drone CI", "is_forum": true}`),
+		"getChat:-1009876543210":       botResult(`{"id": -1009876543210, "type": "channel", "title": "Releases"}`),
+		"getChat:-1005555555555":       botResult(`{"id": -1005555555555, "type": "supergroup", "title": "Chat", "permissions": {"can_send_messages": true}}`),
+		"getChat:222":                  botError(400, "Bad Request: chat not found"),
+		"getChatMember:-1001234567890": botResult(`{"status": "member", "user": {"id": 999, "is_bot": true, "first_name": "drone"}}`),
+		"getChatMember:-1009876543210": botResult(`{"status": "member", "user": {"id": 999, "is_bot": true, "first_name": "drone"}}`),
+		"getChatMember:-1005555555555": botResult(`{"status": "administrator", "user": {"id": 999, "is_bot": true, "first_name": "drone"}}`),
+		"sendChatAction":               botResult(`true`),
+	})
+
+	return server.URL
+}
+
+// problems returns the problems of checks as text and clears them.
+func problems(checks []chatCheck) []string {
+	texts := make([]string, len(checks))
+	for i := range checks {
+		if checks[i].Problem != nil {
+			texts[i] = checks[i].Problem.Error()
+			checks[i].Problem = nil
+		}
+	}
+
+	return texts
+}
+
+func TestCheckChats(t *testing.T) {
+	plugin := Plugin{
+		Config: Config{
+			Token:  "123456:ABC",
+			To:     []string{"111", "-1001234567890", "-1009876543210", "-1005555555555", "222"},
+			APIURL: newCheckServer(t),
+		},
+	}
+
+	bot, err := plugin.newBot(t.Context())
+	require.NoError(t, err)
+
+	checks := plugin.checkChats(t.Context(), bot)
+	err = checkError(checks)
+	assert.EqualError(
+		t,
+		err,
+		"chat -1009876543210: bot cannot post messages (status member)\nchat 222: Bad Request: chat not found",
+	)
+	assert.ErrorIs(t, checks[2].Problem, ErrForbidden)
+	assert.ErrorIs(t, checks[4].Problem, ErrChatNotFound)
+	assert.Equal(t, 4, exitCode(err))
+
+	assert.Equal(
+		t,
+		[]string{"", "", "bot cannot post messages (status member)", "", "Bad Request: chat not found"},
+		problems(checks),
+	)
+	assert.Equal(
+		t,
+		[]chatCheck{
+			{ChatID: 111, Type: "private", Title: "@appleboy", CanPost: true},
+			{ChatID: -1001234567890, Type: "supergroup", Title: "Drone CI", CanPost: true, IsForum: true},
+			{ChatID: -1009876543210, Type: "channel", Title: "Releases"},
+			{ChatID: -1005555555555, Type: "supergroup", Title: "Chat", CanPost: true},
+			{ChatID: 222},
+		},
+		checks,
+	)
+
+	// the thread is probed in forums only
+	plugin.Config.To = []string{"-1001234567890", "-1005555555555"}
+	plugin.Config.MessageThreadID = 7
+	checks = plugin.checkChats(t.Context(), bot)
+	assert.Equal(t, "ok", checks[0].Thread)
+	assert.NoError(t, checks[0].Problem)
+	assert.Equal(t, "no forum", checks[1].Thread)
+	assert.EqualError(t, checks[1].Problem, "message thread 7 is set but the chat is not a forum")
+}
+
+func TestCheckCommand(t *testing.T) {
+	apiURL := newCheckServer(t)
+
+	app := cli.NewApp()
+	var buf bytes.Buffer
+	app.Writer = &buf
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "token"},
+		cli.StringSliceFlag{Name: "to"},
+		cli.StringFlag{Name: "api.url"},
+		cli.StringFlag{Name: "provider", Value: providerDrone},
+	}
+	app.Commands = []cli.Command{checkCommand}
+
+	require.NoError(t, app.Run([]string{
+		"telegram", "--token", "123456:ABC", "--api.url", apiURL, "--to", "-1001234567890", "check",
+	}))
+	assert.Equal(
+		t,
+		"Bot @drone_bot (999)\n\n"+
+			"CHAT ID         TYPE        TITLE     CAN POST  FORUM  THREAD  PROBLEM\n"+
+			"-1001234567890  supergroup  Drone CI  yes       yes            \n",
+		buf.String(),
+	)
+
+	err := app.Run([]string{
+		"telegram", "--token", "123456:ABC", "--api.url", apiURL, "--to", "222", "check",
+	})
+	assert.EqualError(t, err, "chat 222: Bad Request: chat not found")
+	assert.Equal(t, 5, exitCode(err))
+}
+
+func TestPreflight(t *testing.T) {
+	plugin := Plugin{
+		Config: Config{
+			Token:     "123456:ABC",
+			To:        []string{"-1009876543210"},
+			Message:   "hello",
+			APIURL:    newCheckServer(t),
+			Preflight: true,
+		},
+	}
+
+	err := plugin.Exec(t.Context())
+	assert.EqualError(t, err, "preflight failed: chat -1009876543210: bot cannot post messages (status member)")
+	assert.Equal(t, 4, exitCode(err))
+	assert.Empty(t, plugin.results)
+
+	// a missing chat keeps its exit code through the preflight
+	plugin.Config.To = []string{"222"}
+	err = plugin.Exec(t.Context())
+	assert.EqualError(t, err, "preflight failed: chat 222: Bad Request: chat not found")
+	assert.Equal(t, 5, exitCode(err))
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"path"
+	"strconv"
+)
+
+// loadCircleCIEnv fills the repository, commit and build information from
+// the CircleCI built-in variables. CircleCI does not expose the job result,
+// pass it with the status setting.
+func (p *Plugin) loadCircleCIEnv(getenv func(string) string) {
+	setString(&p.Repo.Namespace, getenv("CIRCLE_PROJECT_USERNAME"))
+	setString(&p.Repo.Name, getenv("CIRCLE_PROJECT_REPONAME"))
+	fullName, repoURL := parseRepoURL(getenv("CIRCLE_REPOSITORY_URL"))
+	if len(getenv("CIRCLE_PROJECT_REPONAME")) > 0 {
+		fullName = p.Repo.Namespace + "/" + p.Repo.Name
+	}
+	setString(&p.Repo.FullName, fullName)
+
+	setString(&p.Commit.Sha, getenv("CIRCLE_SHA1"))
+	setString(&p.Commit.Branch, getenv("CIRCLE_BRANCH"))
+	setString(&p.Commit.Author, getenv("CIRCLE_USERNAME"))
+	if len(repoURL) > 0 && len(p.Commit.Sha) > 0 {
+		p.Commit.Link = repoURL + "/commit/" + p.Commit.Sha
+	}
+
+	if number, err := strconv.Atoi(getenv("CIRCLE_BUILD_NUM")); err == nil {
+		p.Build.Number = number
+	}
+	setString(&p.Build.Link, getenv("CIRCLE_BUILD_URL"))
+	setString(&p.Build.Tag, getenv("CIRCLE_TAG"))
+
+	// CIRCLE_PR_NUMBER is only set for forked pull requests
+	pullRequest := getenv("CIRCLE_PULL_REQUEST")
+	if len(pullRequest) > 0 {
+		p.Commit.Link = pullRequest
+	}
+	setString(&p.Build.PR, getenv("CIRCLE_PR_NUMBER"), path.Base(pullRequest))
+
+	switch {
+	case len(p.Build.Tag) > 0:
+		p.Build.Event = "tag"
+	case len(pullRequest) > 0:
+		p.Build.Event = "pull_request"
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCircleCIEnv(t *testing.T) {
+	plugin := Plugin{
+		Build:  Build{Event: "push"},
+		Config: Config{Provider: providerCircleCI},
+	}
+
+	plugin.loadCircleCIEnv(mapEnv(map[string]string{
+		"CIRCLECI":                "true",
+		"CIRCLE_PROJECT_USERNAME": "appleboy",
+		"CIRCLE_PROJECT_REPONAME": "go-hello",
+		"CIRCLE_REPOSITORY_URL":   "git@github.com:appleboy/go-hello.git",
+		"CIRCLE_SHA1":             "e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2",
+		"CIRCLE_BRANCH":           "master",
+		"CIRCLE_USERNAME":         "appleboy",
+		"CIRCLE_BUILD_NUM":        "128",
+		"CIRCLE_BUILD_URL":        "https://circleci.com/gh/appleboy/go-hello/128",
+	}))
+
+	assert.Equal(t, "appleboy/go-hello", plugin.Repo.FullName)
+	assert.Equal(t, "master", plugin.Commit.Branch)
+	assert.Equal(t, "appleboy", plugin.Commit.Author)
+	assert.Equal(t, "https://github.com/appleboy/go-hello/commit/e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2", plugin.Commit.Link)
+	assert.Equal(t, 128, plugin.Build.Number)
+	assert.Equal(t, "https://circleci.com/gh/appleboy/go-hello/128", plugin.Build.Link)
+	assert.Equal(t, "push", plugin.Build.Event)
+}
+
+func TestLoadCircleCIEnvPullRequest(t *testing.T) {
+	plugin := Plugin{}
+
+	plugin.loadCircleCIEnv(mapEnv(map[string]string{
+		"CIRCLE_REPOSITORY_URL": "https://github.com/appleboy/go-hello",
+		"CIRCLE_PULL_REQUEST":   "https://github.com/appleboy/go-hello/pull/8",
+	}))
+
+	assert.Equal(t, "appleboy/go-hello", plugin.Repo.FullName)
+	assert.Equal(t, "pull_request", plugin.Build.Event)
+	assert.Equal(t, "8", plugin.Build.PR)
+	assert.Equal(t, "https://github.com/appleboy/go-hello/pull/8", plugin.Commit.Link)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+)
+
+// The classes of the Bot API failures. errors.Is matches a failure against
+// its class, and the exit code of the plugin tells them apart.
+var (
+	ErrRateLimited  = errors.New("rate limited")
+	ErrForbidden    = errors.New("forbidden")
+	ErrChatNotFound = errors.New("chat not found")
+	ErrBadRequest   = errors.New("bad request")
+	ErrTooLarge     = errors.New("request too large")
+	ErrNetwork      = errors.New("network error")
+)
+
+// exitCodes maps the classes of failures onto the exit codes of the
+// plugin, any other failure exits with 1. When several failures are joined,
+// the first class of this list wins.
+var exitCodes = []struct {
+	err  error
+	code int
+}{
+	{ErrRateLimited, 3},
+	{ErrForbidden, 4},
+	{ErrChatNotFound, 5},
+	{ErrBadRequest, 6},
+	{ErrTooLarge, 7},
+	{ErrNetwork, 8},
+}
+
+// exitCode returns the exit code for an error of the plugin.
+func exitCode(err error) int {
+	for _, e := range exitCodes {
+		if errors.Is(err, e.err) {
+			return e.code
+		}
+	}
+
+	return 1
+}
+
+// classifiedError is a failure of the Bot API, with the secrets hidden in
+// its message. It unwraps to its class and to the failure itself.
+type classifiedError struct {
+	class error
+	err   error
+}
+
+func (e *classifiedError) Error() string { return e.err.Error() }
+
+func (e *classifiedError) Unwrap() []error { return []error{e.class, e.err} }
+
+// apiError hides the secrets of a Bot API failure and classifies it.
+func (p *Plugin) apiError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	class := classify(err)
+	err = p.redact(err)
+	if class == nil {
+		return err
+	}
+
+	return &classifiedError{class: class, err: err}
+}
+
+// classify returns the class of a Bot API failure, nil when it has none.
+func classify(err error) error {
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) {
+		description := strings.ToLower(tgErr.Message)
+		switch {
+		case tgErr.Code == http.StatusTooManyRequests || tgErr.RetryAfter > 0:
+			return ErrRateLimited
+		case tgErr.Code == http.StatusForbidden:
+			// bot was blocked by the user, kicked from the chat, ...
+			return ErrForbidden
+		case tgErr.Code == http.StatusRequestEntityTooLarge,
+			strings.Contains(description, "too large"),
+			strings.Contains(description, "too big"),
+			strings.Contains(description, "too long"):
+			return ErrTooLarge
+		case strings.Contains(description, "chat not found"):
+			return ErrChatNotFound
+		case tgErr.Code == http.StatusBadRequest:
+			// can't parse entities, message thread not found, ...
+			return ErrBadRequest
+		}
+		return nil
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return ErrNetwork
+	}
+
+	return nil
+}
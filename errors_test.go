@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifiedErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		class    error
+		code     int
+	}{
+		{
+			name:     "rate limited",
+			response: `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 5","parameters":{"retry_after":5}}`,
+			class:    ErrRateLimited,
+			code:     3,
+		},
+		{
+			name:     "forbidden",
+			response: botError(403, "Forbidden: bot was blocked by the user"),
+			class:    ErrForbidden,
+			code:     4,
+		},
+		{
+			name:     "chat not found",
+			response: botError(400, "Bad Request: chat not found"),
+			class:    ErrChatNotFound,
+			code:     5,
+		},
+		{
+			name:     "parse error",
+			response: botError(400, "Bad Request: can't parse entities: Can't find end of the entity starting at byte offset 0"),
+			class:    ErrBadRequest,
+			code:     6,
+		},
+		{
+			name:     "too large",
+			response: botError(413, "Request Entity Too Large"),
+			class:    ErrTooLarge,
+			code:     7,
+		},
+		{
+			name:     "message too long",
+			response: botError(400, "Bad Request: message is too long"),
+			class:    ErrTooLarge,
+			code:     7,
+		},
+		{
+			name:     "unclassified",
+			response: botError(409, "Conflict"),
+			code:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newBotServer(t, map[string]string{
+				"getMe":       botResult(testBot),
+				"sendMessage": tt.response,
+			})
+
+			plugin := Plugin{
+				Config: Config{
+					Token:   "123456:ABC",
+					To:      []string{"1234567890"},
+					Message: "hello",
+					APIURL:  server.URL,
+				},
+			}
+
+			err := plugin.Exec(t.Context())
+			require.Error(t, err)
+			if tt.class != nil {
+				assert.ErrorIs(t, err, tt.class)
+			}
+			assert.Equal(t, tt.code, exitCode(err))
+
+			// the failure of the bot library is still there
+			var tgErr *tgbotapi.Error
+			assert.ErrorAs(t, err, &tgErr)
+
+			// classes survive wrapping
+			assert.Equal(t, tt.code, exitCode(fmt.Errorf("profile default: %w", err)))
+		})
+	}
+}
+
+func TestNetworkError(t *testing.T) {
+	// nothing listens on the server after it is closed
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	plugin := Plugin{
+		Config: Config{
+			Token:   "123456:ABC-DEF",
+			To:      []string{"1234567890"},
+			Message: "hello",
+			APIURL:  server.URL,
+		},
+	}
+
+	err := plugin.Exec(t.Context())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrNetwork)
+	assert.Equal(t, 8, exitCode(err))
+	assert.NotContains(t, err.Error(), "123456:ABC-DEF")
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 1, exitCode(errors.New("missing telegram token or user list")))
+
+	// the first class of the list wins
+	err := errors.Join(
+		&classifiedError{class: ErrNetwork, err: errors.New("dial tcp: connection refused")},
+		&classifiedError{class: ErrForbidden, err: errors.New("Forbidden: bot was kicked")},
+	)
+	assert.Equal(t, 4, exitCode(err))
+	assert.Equal(t, "dial tcp: connection refused\nForbidden: bot was kicked", err.Error())
+}
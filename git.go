@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// parseRepoURL returns the owner/name path and the web address of a git
+// remote, accepting https, ssh and scp-like (git@host:owner/name.git) URLs.
+func parseRepoURL(remote string) (fullName, webURL string) {
+	remote = strings.TrimSpace(remote)
+	if len(remote) == 0 {
+		return "", ""
+	}
+
+	// git@github.com:appleboy/go-hello.git
+	if !strings.Contains(remote, "://") {
+		host, path, ok := strings.Cut(remote, ":")
+		if !ok {
+			return "", ""
+		}
+		if _, after, found := strings.Cut(host, "@"); found {
+			host = after
+		}
+		remote = "ssh://" + host + "/" + path
+	}
+
+	u, err := url.Parse(remote)
+	if err != nil || len(u.Host) == 0 {
+		return "", ""
+	}
+
+	fullName = strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if len(fullName) == 0 {
+		return "", ""
+	}
+
+	return fullName, "https://" + u.Hostname() + "/" + fullName
+}
+
+// gitTimeout bounds the git call of loadGitCommit.
+const gitTimeout = 10 * time.Second
+
+// loadGitEnv fills the missing commit author and message from the standard
+// GIT_* variables, which Jenkins exports and other CI systems can pass on.
+func (p *Plugin) loadGitEnv(getenv func(string) string) {
+	setString(&p.Commit.Author, p.Commit.Author, getenv("GIT_AUTHOR_NAME"), getenv("GIT_COMMITTER_NAME"))
+	setString(&p.Commit.Email, p.Commit.Email, getenv("GIT_AUTHOR_EMAIL"), getenv("GIT_COMMITTER_EMAIL"))
+	setString(&p.Commit.Message, p.Commit.Message, getenv("GIT_COMMIT_MESSAGE"))
+}
+
+// loadGitCommit fills the commit author and message from the git checkout in
+// the working directory, for CI systems that do not export them. The
+// Docker image ships without git, so it fails and leaves them empty there.
+func (p *Plugin) loadGitCommit() error {
+	if len(p.Commit.Author) > 0 && len(p.Commit.Message) > 0 {
+		return nil
+	}
+
+	rev := p.Commit.Sha
+	if len(rev) == 0 {
+		rev = "HEAD"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	// a mounted checkout is usually owned by another user than the container
+	out, err := exec.CommandContext(ctx,
+		"git", "-c", "safe.directory=*", "log", "-1", "--format=%an%n%ae%n%B", rev,
+	).Output()
+	if err != nil {
+		return err
+	}
+
+	fields := strings.SplitN(strings.TrimSpace(string(out)), "\n", 3)
+	if len(fields) < 3 {
+		return nil
+	}
+
+	setString(&p.Commit.Author, p.Commit.Author, fields[0])
+	setString(&p.Commit.Email, p.Commit.Email, fields[1])
+	setString(&p.Commit.Message, p.Commit.Message, strings.TrimSpace(fields[2]))
+
+	return nil
+}
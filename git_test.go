@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRepoURL(t *testing.T) {
+	tests := map[string][2]string{
+		"https://github.com/appleboy/go-hello.git":     {"appleboy/go-hello", "https://github.com/appleboy/go-hello"},
+		"https://github.com/appleboy/go-hello":         {"appleboy/go-hello", "https://github.com/appleboy/go-hello"},
+		"git@github.com:appleboy/go-hello.git":         {"appleboy/go-hello", "https://github.com/appleboy/go-hello"},
+		"ssh://git@gitlab.com:22/group/sub/repo.git":   {"group/sub/repo", "https://gitlab.com/group/sub/repo"},
+		"https://user@bitbucket.org/appleboy/go-hello": {"appleboy/go-hello", "https://bitbucket.org/appleboy/go-hello"},
+		"":          {"", ""},
+		"go-hello":  {"", ""},
+		"https://x": {"", ""},
+	}
+
+	for remote, want := range tests {
+		fullName, webURL := parseRepoURL(remote)
+		assert.Equal(t, want[0], fullName, remote)
+		assert.Equal(t, want[1], webURL, remote)
+	}
+}
+
+func TestLoadGitCommit(t *testing.T) {
+	plugin := Plugin{Commit: Commit{Author: "appleboy", Message: "keep me"}}
+	require.NoError(t, plugin.loadGitCommit())
+	assert.Equal(t, "appleboy", plugin.Commit.Author)
+	assert.Equal(t, "keep me", plugin.Commit.Message)
+
+	// unknown revisions leave the commit untouched
+	plugin = Plugin{Commit: Commit{Sha: "0000000000000000000000000000000000000000"}}
+	assert.Error(t, plugin.loadGitCommit())
+	assert.Empty(t, plugin.Commit.Author)
+	assert.Empty(t, plugin.Commit.Message)
+}
+
+func TestExecWarnsAboutGit(t *testing.T) {
+	var buf bytes.Buffer
+	plugin := Plugin{
+		Config: Config{
+			To:        []string{"1234567890"},
+			Message:   "hello",
+			DryRun:    true,
+			LogFormat: logFormatJSON,
+		},
+		gitErr:    assert.AnError,
+		logOutput: &buf,
+	}
+	require.NoError(t, plugin.Exec(t.Context()))
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "WARN", entries[0]["level"])
+	assert.Equal(t, assert.AnError.Error(), entries[0]["error"])
+}
+
+func TestLoadGitEnv(t *testing.T) {
+	plugin := Plugin{Commit: Commit{Author: "appleboy"}}
+	plugin.loadGitEnv(mapEnv(map[string]string{
+		"GIT_AUTHOR_NAME":     "Bo-Yi Wu",
+		"GIT_COMMITTER_EMAIL": "appleboy.tw@gmail.com",
+		"GIT_COMMIT_MESSAGE":  "chore: update default template",
+	}))
+
+	assert.Equal(t, "appleboy", plugin.Commit.Author)
+	assert.Equal(t, "appleboy.tw@gmail.com", plugin.Commit.Email)
+	assert.Equal(t, "chore: update default template", plugin.Commit.Message)
+}
@@ -0,0 +1,27 @@
+package main
+
+// loadGiteaEnv fills the GitHub compatible information from the GITEA_* and
+// FORGEJO_* variables that Gitea and Forgejo Actions export next to, or
+// instead of, the GITHUB_* ones.
+func (p *Plugin) loadGiteaEnv(getenv func(string) string) {
+	// GITHUB_* wins over GITEA_*, and GITEA_* over FORGEJO_*
+	env := func(key string) string {
+		for _, prefix := range []string{"GITHUB_", "GITEA_", "FORGEJO_"} {
+			if value := getenv(prefix + key); len(value) > 0 {
+				return value
+			}
+		}
+		return ""
+	}
+
+	setString(&p.GitHub.ServerURL, env("SERVER_URL"))
+	setString(&p.GitHub.Workflow, env("WORKFLOW"))
+	setString(&p.GitHub.EventName, env("EVENT_NAME"))
+	setString(&p.GitHub.EventPath, env("EVENT_PATH"))
+	setString(&p.GitHub.RunID, env("RUN_ID"))
+	setString(&p.Repo.FullName, env("REPOSITORY"))
+	setString(&p.Repo.Namespace, env("ACTOR"))
+	setString(&p.Commit.Sha, env("SHA"))
+	setString(&p.Commit.Ref, env("REF"))
+	setString(&p.Commit.Branch, env("REF_NAME"))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGiteaEnv(t *testing.T) {
+	plugin := Plugin{
+		Commit: Commit{Branch: "master"},
+		Build:  Build{Number: 5, Event: "push", Status: "success"},
+		Config: Config{Provider: providerGitea},
+	}
+
+	plugin.loadGiteaEnv(mapEnv(map[string]string{
+		"FORGEJO_SERVER_URL": "https://codeberg.org",
+		"FORGEJO_WORKFLOW":   "ci",
+		"FORGEJO_EVENT_NAME": "push",
+		"FORGEJO_EVENT_PATH": "tests/github_event_push.json",
+		"FORGEJO_RUN_ID":     "88123",
+		"FORGEJO_REPOSITORY": "appleboy/go-hello",
+		"FORGEJO_ACTOR":      "appleboy",
+		"FORGEJO_SHA":        "e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2",
+		"FORGEJO_REF":        "refs/heads/main",
+		"FORGEJO_REF_NAME":   "main",
+	}))
+
+	assert.Equal(t, "https://codeberg.org", plugin.GitHub.ServerURL)
+	assert.Equal(t, "appleboy/go-hello", plugin.Repo.FullName)
+	assert.Equal(t, "main", plugin.Commit.Branch)
+
+	require.NoError(t, plugin.GitHub.loadEvent())
+	plugin.fillFromGitHub()
+
+	assert.Equal(t, "https://codeberg.org/appleboy/go-hello/actions/runs/5", plugin.Build.Link)
+	assert.Equal(t, "https://codeberg.org/appleboy/go-hello/commit/e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2", plugin.Commit.Link)
+	assert.Equal(
+		t,
+		[]string{
+			"✅ Workflow `ci` #5 of `appleboy/go-hello` success.\n\n📝 Commit by Bo-Yi Wu on `main`:\n``` chore: update default template ```\n\n🌐 https://codeberg.org/appleboy/go-hello/actions/runs/5",
+		},
+		plugin.Message(),
+	)
+}
+
+func TestLoadGiteaEnvPrecedence(t *testing.T) {
+	// the flags already read the GITHUB_* variables
+	plugin := Plugin{
+		GitHub: GitHub{ServerURL: "https://git.example.com", Workflow: "build"},
+		Commit: Commit{Branch: "master"},
+	}
+
+	plugin.loadGiteaEnv(mapEnv(map[string]string{
+		"GITHUB_SERVER_URL":  "https://git.example.com",
+		"GITHUB_WORKFLOW":    "build",
+		"GITEA_SERVER_URL":   "https://gitea.example.com",
+		"GITEA_WORKFLOW":     "gitea",
+		"GITEA_REPOSITORY":   "appleboy/gitea",
+		"FORGEJO_SERVER_URL": "https://forgejo.example.com",
+		"FORGEJO_REPOSITORY": "appleboy/forgejo",
+		"FORGEJO_RUN_ID":     "42",
+		"FORGEJO_REF_NAME":   "main",
+	}))
+
+	assert.Equal(t, "https://git.example.com", plugin.GitHub.ServerURL)
+	assert.Equal(t, "build", plugin.GitHub.Workflow)
+	assert.Equal(t, "appleboy/gitea", plugin.Repo.FullName)
+	assert.Equal(t, "42", plugin.GitHub.RunID)
+	assert.Equal(t, "main", plugin.Commit.Branch)
+}
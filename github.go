@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultGitHubServerURL = "https://github.com"
+
+type (
+	// GitHubEvent is the part of the GitHub Actions event payload that is
+	// available to templates as github.event. The complete payload is
+	// available as github.payload.
+	GitHubEvent struct {
+		Action      string            `json:"action"`
+		Ref         string            `json:"ref"`
+		Before      string            `json:"before"`
+		After       string            `json:"after"`
+		Compare     string            `json:"compare"`
+		Pusher      GitHubPusher      `json:"pusher"`
+		Sender      GitHubUser        `json:"sender"`
+		HeadCommit  GitHubCommit      `json:"head_commit" handlebars:"head_commit"`
+		Commits     []GitHubCommit    `json:"commits"`
+		PullRequest GitHubPullRequest `json:"pull_request" handlebars:"pull_request"`
+		Release     GitHubRelease     `json:"release"`
+	}
+
+	// GitHubPusher is the user who pushed the commits.
+	GitHubPusher struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
+	// GitHubUser is a GitHub account.
+	GitHubUser struct {
+		Login   string `json:"login"`
+		HTMLURL string `json:"html_url" handlebars:"html_url"`
+	}
+
+	// GitHubCommit is a pushed commit.
+	GitHubCommit struct {
+		ID        string           `json:"id" handlebars:"id"`
+		Message   string           `json:"message"`
+		Timestamp string           `json:"timestamp"`
+		URL       string           `json:"url" handlebars:"url"`
+		Author    GitHubCommitUser `json:"author"`
+	}
+
+	// GitHubCommitUser is the git author of a commit.
+	GitHubCommitUser struct {
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Username string `json:"username"`
+	}
+
+	// GitHubPullRequest is the pull request of a pull_request event.
+	GitHubPullRequest struct {
+		Number  int          `json:"number"`
+		Title   string       `json:"title"`
+		Body    string       `json:"body"`
+		State   string       `json:"state"`
+		Draft   bool         `json:"draft"`
+		Merged  bool         `json:"merged"`
+		HTMLURL string       `json:"html_url" handlebars:"html_url"`
+		User    GitHubUser   `json:"user"`
+		Head    GitHubBranch `json:"head"`
+		Base    GitHubBranch `json:"base"`
+	}
+
+	// GitHubBranch is the head or base of a pull request.
+	GitHubBranch struct {
+		Ref   string `json:"ref"`
+		Sha   string `json:"sha"`
+		Label string `json:"label"`
+	}
+
+	// GitHubRelease is the release of a release event.
+	GitHubRelease struct {
+		TagName    string     `json:"tag_name" handlebars:"tag_name"`
+		Name       string     `json:"name"`
+		Body       string     `json:"body"`
+		Draft      bool       `json:"draft"`
+		Prerelease bool       `json:"prerelease"`
+		HTMLURL    string     `json:"html_url" handlebars:"html_url"`
+		Author     GitHubUser `json:"author"`
+	}
+)
+
+// loadEvent parses the event payload file of the workflow run.
+func (g *GitHub) loadEvent() error {
+	content, err := os.ReadFile(g.EventPath)
+	if err != nil {
+		return fmt.Errorf("unable to read GitHub event file '%s': %w", g.EventPath, err)
+	}
+
+	if err := json.Unmarshal(content, &g.Event); err != nil {
+		return fmt.Errorf("unable to unmarshal GitHub event file '%s': %w", g.EventPath, err)
+	}
+
+	if err := json.Unmarshal(content, &g.Payload); err != nil {
+		return fmt.Errorf("unable to unmarshal GitHub event file '%s': %w", g.EventPath, err)
+	}
+
+	return nil
+}
+
+// buildEvent maps the GitHub event that triggered the workflow onto the
+// build events used by the built-in message.
+func (g GitHub) buildEvent(ref string) string {
+	switch g.EventName {
+	case "pull_request", "pull_request_target":
+		return "pull_request"
+	case "release":
+		return "tag"
+	case "schedule":
+		return "cron"
+	case "push":
+		if strings.HasPrefix(ref, "refs/tags/") {
+			return "tag"
+		}
+	}
+
+	return g.EventName
+}
+
+// fillFromGitHub completes the build and commit information with the
+// workflow run environment and the event payload.
+func (p *Plugin) fillFromGitHub() {
+	g := p.GitHub
+	serverURL := strings.TrimSuffix(g.ServerURL, "/")
+	if len(serverURL) == 0 {
+		serverURL = defaultGitHubServerURL
+	}
+	repoURL := serverURL + "/" + p.Repo.FullName
+
+	if len(g.EventName) > 0 {
+		p.Build.Event = g.buildEvent(p.Commit.Ref)
+	}
+
+	runID := g.RunID
+	if p.Config.Provider == providerGitea && p.Build.Number > 0 {
+		// Gitea and Forgejo address a run by its number
+		runID = strconv.Itoa(p.Build.Number)
+	}
+	if len(p.Build.Link) == 0 && len(runID) > 0 {
+		p.Build.Link = repoURL + "/actions/runs/" + runID
+	}
+
+	head := g.Event.HeadCommit
+	if len(p.Commit.Message) == 0 {
+		p.Commit.Message = head.Message
+	}
+	if len(p.Commit.Author) == 0 {
+		p.Commit.Author = head.Author.Name
+	}
+	if len(p.Commit.Email) == 0 {
+		p.Commit.Email = head.Author.Email
+	}
+
+	switch p.Build.Event {
+	case "pull_request":
+		pr := g.Event.PullRequest
+		if pr.Number > 0 {
+			p.Build.PR = strconv.Itoa(pr.Number)
+			// GITHUB_REF_NAME is the merge ref, e.g. 42/merge
+			p.Commit.Branch = pr.Base.Ref
+		}
+		if len(p.Commit.Message) == 0 {
+			p.Commit.Message = pr.Title
+		}
+		if len(p.Commit.Author) == 0 {
+			p.Commit.Author = pr.User.Login
+		}
+		if len(p.Commit.Link) == 0 {
+			p.Commit.Link = pr.HTMLURL
+		}
+	case "tag":
+		if len(p.Build.Tag) == 0 {
+			p.Build.Tag = g.Event.Release.TagName
+		}
+		if len(p.Build.Tag) == 0 {
+			p.Build.Tag = strings.TrimPrefix(p.Commit.Ref, "refs/tags/")
+		}
+		if len(p.Commit.Author) == 0 {
+			p.Commit.Author = g.Event.Release.Author.Login
+		}
+	}
+
+	// fall back to the user who triggered the workflow
+	if len(p.Commit.Author) == 0 {
+		p.Commit.Author = p.Repo.Namespace
+	}
+
+	if len(p.Commit.Link) == 0 && len(p.Commit.Sha) > 0 {
+		p.Commit.Link = repoURL + "/commit/" + p.Commit.Sha
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/appleboy/drone-template-lib/template"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGitHubPushEvent(t *testing.T) {
+	plugin := Plugin{
+		GitHub: GitHub{
+			EventName: "push",
+			EventPath: "tests/github_event_push.json",
+		},
+	}
+
+	require.NoError(t, plugin.GitHub.loadEvent())
+
+	event := plugin.GitHub.Event
+	assert.Equal(t, "refs/heads/master", event.Ref)
+	assert.Equal(t, "appleboy", event.Pusher.Name)
+	assert.Equal(t, "chore: update default template", event.HeadCommit.Message)
+	assert.Equal(t, "Bo-Yi Wu", event.HeadCommit.Author.Name)
+	require.Len(t, event.Commits, 2)
+	assert.Equal(t, "fix: typo", event.Commits[0].Message)
+
+	rendered, err := template.RenderTrim(
+		`{{github.event.pusher.name}} pushed {{github.event.commits.length}} commits to {{github.payload.repository.full_name}}:
+{{#each github.event.commits}}- {{truncate id 7}} {{message}}
+{{/each}}`,
+		plugin,
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"appleboy pushed 2 commits to appleboy/go-hello:\n- 0d1a26e fix: typo\n- e7c4f0a chore: update default template",
+		rendered,
+	)
+}
+
+func TestLoadGitHubPullRequestEvent(t *testing.T) {
+	plugin := Plugin{
+		GitHub: GitHub{
+			EventName: "pull_request",
+			EventPath: "tests/github_event_pull_request.json",
+		},
+	}
+
+	require.NoError(t, plugin.GitHub.loadEvent())
+
+	pr := plugin.GitHub.Event.PullRequest
+	assert.Equal(t, 42, pr.Number)
+	assert.Equal(t, "feature/topics", pr.Head.Ref)
+	assert.Equal(t, "master", pr.Base.Ref)
+
+	rendered, err := template.RenderTrim(
+		"PR #{{github.event.pull_request.number}} {{github.event.pull_request.title}} "+
+			"({{github.event.pull_request.head.ref}} → {{github.event.pull_request.base.ref}}) "+
+			"{{github.event.pull_request.html_url}}",
+		plugin,
+	)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"PR #42 feat: support forum topics (feature/topics → master) https://github.com/appleboy/go-hello/pull/42",
+		rendered,
+	)
+}
+
+func TestLoadGitHubReleaseEvent(t *testing.T) {
+	plugin := Plugin{
+		GitHub: GitHub{
+			EventName: "release",
+			EventPath: "tests/github_event_release.json",
+		},
+	}
+
+	require.NoError(t, plugin.GitHub.loadEvent())
+
+	release := plugin.GitHub.Event.Release
+	assert.Equal(t, "v1.2.0", release.TagName)
+	assert.Equal(t, "## What's Changed\n* support forum topics", release.Body)
+
+	rendered, err := template.RenderTrim("{{github.event.release.tag_name}} published", plugin)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.2.0 published", rendered)
+}
+
+func TestLoadGitHubEventError(t *testing.T) {
+	github := GitHub{EventPath: "tests/not_found.json"}
+	assert.Error(t, github.loadEvent())
+
+	github = GitHub{EventPath: "tests/message.txt"}
+	assert.Error(t, github.loadEvent())
+}
+
+func TestRenderIgnoresBrokenEvent(t *testing.T) {
+	for _, provider := range []string{providerDrone, providerGitHub} {
+		for _, eventPath := range []string{"tests/not_found.json", "tests/message.txt"} {
+			var buf bytes.Buffer
+			plugin := Plugin{
+				GitHub: GitHub{EventName: "push", EventPath: eventPath},
+				Build:  Build{Number: 1, Status: "success"},
+				Config: Config{
+					Provider:  provider,
+					Message:   "build {{build.number}} {{build.status}}",
+					LogFormat: logFormatJSON,
+				},
+				logOutput: &buf,
+			}
+
+			messages, err := plugin.render()
+			require.NoError(t, err, provider+" "+eventPath)
+			assert.Equal(t, []string{"build 1 success"}, messages)
+
+			// only github reads the event, and warns in the format of the log
+			entries := logEntries(t, &buf)
+			if provider == providerDrone {
+				assert.Empty(t, entries)
+				continue
+			}
+			require.Len(t, entries, 1)
+			assert.Equal(t, "ignoring the event of the workflow", entries[0]["msg"])
+			assert.Equal(t, eventPath, entries[0]["path"])
+		}
+	}
+}
+
+func TestFillFromGitHubPullRequest(t *testing.T) {
+	plugin := Plugin{
+		Config: Config{
+			Provider: providerGitHub,
+		},
+		Repo: Repo{
+			FullName:  "appleboy/go-hello",
+			Namespace: "octocat",
+		},
+		Commit: Commit{
+			Sha:    "9a2b8c1f0e4d5b6a7c8d9e0f1a2b3c4d5e6f7a8b",
+			Ref:    "refs/pull/42/merge",
+			Branch: "42/merge",
+		},
+		Build: Build{
+			Number: 7,
+			Event:  "push",
+			Status: "success",
+		},
+		GitHub: GitHub{
+			Workflow:  "CI",
+			EventName: "pull_request",
+			EventPath: "tests/github_event_pull_request.json",
+			ServerURL: "https://github.example.com/",
+			RunID:     "99",
+		},
+	}
+
+	require.NoError(t, plugin.GitHub.loadEvent())
+	plugin.fillFromGitHub()
+
+	assert.Equal(t, "pull_request", plugin.Build.Event)
+	assert.Equal(t, "42", plugin.Build.PR)
+	assert.Equal(t, "master", plugin.Commit.Branch)
+	assert.Equal(t, "feat: support forum topics", plugin.Commit.Message)
+	assert.Equal(t, "octocat", plugin.Commit.Author)
+	assert.Equal(t, "https://github.com/appleboy/go-hello/pull/42", plugin.Commit.Link)
+	assert.Equal(t, "https://github.example.com/appleboy/go-hello/actions/runs/99", plugin.Build.Link)
+
+	assert.Equal(
+		t,
+		[]string{
+			"✅ Workflow `CI` #7 of `appleboy/go-hello` success.\n\n🔀 PR #42 by octocat into `master`:\n``` feat: support forum topics ```\n\n🔗 https://github.com/appleboy/go-hello/pull/42\n🌐 https://github.example.com/appleboy/go-hello/actions/runs/99",
+		},
+		plugin.Message(),
+	)
+}
+
+func TestFillFromGitHubRelease(t *testing.T) {
+	plugin := Plugin{
+		Repo: Repo{
+			FullName: "appleboy/go-hello",
+		},
+		Commit: Commit{
+			Sha: "e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2",
+			Ref: "refs/tags/v1.2.0",
+		},
+		GitHub: GitHub{
+			EventName: "release",
+			EventPath: "tests/github_event_release.json",
+		},
+	}
+
+	require.NoError(t, plugin.GitHub.loadEvent())
+	plugin.fillFromGitHub()
+
+	assert.Equal(t, "tag", plugin.Build.Event)
+	assert.Equal(t, "v1.2.0", plugin.Build.Tag)
+	assert.Equal(t, "appleboy", plugin.Commit.Author)
+	assert.Equal(t, "https://github.com/appleboy/go-hello/commit/e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2", plugin.Commit.Link)
+	assert.Empty(t, plugin.Build.Link)
+}
+
+func TestGitHubBuildEvent(t *testing.T) {
+	tests := []struct {
+		event string
+		ref   string
+		want  string
+	}{
+		{"push", "refs/heads/master", "push"},
+		{"push", "refs/tags/v1.0.0", "tag"},
+		{"pull_request", "refs/pull/1/merge", "pull_request"},
+		{"pull_request_target", "refs/heads/master", "pull_request"},
+		{"release", "refs/tags/v1.0.0", "tag"},
+		{"schedule", "refs/heads/master", "cron"},
+		{"workflow_dispatch", "refs/heads/master", "workflow_dispatch"},
+	}
+
+	for _, tt := range tests {
+		github := GitHub{EventName: tt.event}
+		assert.Equal(t, tt.want, github.buildEvent(tt.ref), tt.event)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/mail"
+	"strconv"
+	"time"
+)
+
+// loadGitLabEnv fills the repository, commit and build information from
+// the GitLab CI predefined variables.
+func (p *Plugin) loadGitLabEnv(getenv func(string) string) {
+	setString(&p.Repo.FullName, getenv("CI_PROJECT_PATH"))
+	setString(&p.Repo.Namespace, getenv("CI_PROJECT_NAMESPACE"))
+	setString(&p.Repo.Name, getenv("CI_PROJECT_NAME"))
+
+	setString(&p.Commit.Sha, getenv("CI_COMMIT_SHA"))
+	setString(&p.Commit.Ref, getenv("CI_COMMIT_REF_NAME"))
+	setString(&p.Commit.Branch,
+		getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME"),
+		getenv("CI_COMMIT_BRANCH"),
+		getenv("CI_DEFAULT_BRANCH"),
+	)
+	setString(&p.Commit.Message, getenv("CI_COMMIT_MESSAGE"), getenv("CI_COMMIT_TITLE"))
+
+	// CI_COMMIT_AUTHOR is "Name <email>"
+	if author, err := mail.ParseAddress(getenv("CI_COMMIT_AUTHOR")); err == nil {
+		setString(&p.Commit.Author, author.Name)
+		setString(&p.Commit.Email, author.Address)
+	} else {
+		setString(&p.Commit.Author, getenv("GITLAB_USER_NAME"), getenv("GITLAB_USER_LOGIN"))
+		setString(&p.Commit.Email, getenv("GITLAB_USER_EMAIL"))
+	}
+
+	projectURL := getenv("CI_PROJECT_URL")
+	iid := getenv("CI_MERGE_REQUEST_IID")
+	switch {
+	case len(iid) > 0 && len(projectURL) > 0:
+		p.Commit.Link = projectURL + "/-/merge_requests/" + iid
+	case len(p.Commit.Sha) > 0 && len(projectURL) > 0:
+		p.Commit.Link = projectURL + "/-/commit/" + p.Commit.Sha
+	}
+
+	if number, err := strconv.Atoi(getenv("CI_PIPELINE_IID")); err == nil {
+		p.Build.Number = number
+	}
+	setString(&p.Build.Link, getenv("CI_PIPELINE_URL"))
+	setString(&p.Build.Status, getenv("CI_JOB_STATUS"))
+	setString(&p.Build.Tag, getenv("CI_COMMIT_TAG"))
+	setString(&p.Build.PR, iid)
+	setString(&p.Build.DeployTo, getenv("CI_ENVIRONMENT_NAME"))
+	if created, err := time.Parse(time.RFC3339, getenv("CI_PIPELINE_CREATED_AT")); err == nil {
+		p.Build.Started = created.Unix()
+	}
+
+	switch {
+	case len(p.Build.Tag) > 0:
+		p.Build.Event = "tag"
+	case len(iid) > 0:
+		p.Build.Event = "pull_request"
+	case getenv("CI_PIPELINE_SOURCE") == "schedule":
+		p.Build.Event = "cron"
+	default:
+		p.Build.Event = "push"
+	}
+}
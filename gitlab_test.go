@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mapEnv(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestLoadGitLabEnv(t *testing.T) {
+	plugin := Plugin{
+		Commit: Commit{Branch: "master"},
+		Build:  Build{Event: "push", Status: "success"},
+		Config: Config{Provider: providerGitLab},
+	}
+
+	plugin.loadGitLabEnv(mapEnv(map[string]string{
+		"CI_PROJECT_PATH":        "appleboy/go-hello",
+		"CI_PROJECT_NAMESPACE":   "appleboy",
+		"CI_PROJECT_NAME":        "go-hello",
+		"CI_PROJECT_URL":         "https://gitlab.com/appleboy/go-hello",
+		"CI_COMMIT_SHA":          "e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2",
+		"CI_COMMIT_REF_NAME":     "main",
+		"CI_COMMIT_BRANCH":       "main",
+		"CI_COMMIT_MESSAGE":      "update gitlab ci",
+		"CI_COMMIT_AUTHOR":       "Bo-Yi Wu <appleboy.tw@gmail.com>",
+		"CI_PIPELINE_IID":        "42",
+		"CI_PIPELINE_URL":        "https://gitlab.com/appleboy/go-hello/-/pipelines/1234567",
+		"CI_PIPELINE_SOURCE":     "push",
+		"CI_PIPELINE_CREATED_AT": "2023-11-14T22:13:20Z",
+		"CI_JOB_STATUS":          "failed",
+		"GITLAB_USER_NAME":       "Someone Else",
+	}))
+
+	assert.Equal(t, Repo{FullName: "appleboy/go-hello", Namespace: "appleboy", Name: "go-hello"}, plugin.Repo)
+	assert.Equal(t, "main", plugin.Commit.Branch)
+	assert.Equal(t, "Bo-Yi Wu", plugin.Commit.Author)
+	assert.Equal(t, "appleboy.tw@gmail.com", plugin.Commit.Email)
+	assert.Equal(t, "https://gitlab.com/appleboy/go-hello/-/commit/e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2", plugin.Commit.Link)
+	assert.Equal(t, 42, plugin.Build.Number)
+	assert.Equal(t, "push", plugin.Build.Event)
+	assert.Equal(t, "failed", plugin.Build.Status)
+	assert.Equal(t, int64(1700000000), plugin.Build.Started)
+
+	plugin.Build.Status = normalizeStatus(plugin.Build.Status)
+	assert.Equal(
+		t,
+		[]string{
+			"❌ Pipeline #42 of `appleboy/go-hello` failure.\n\n📝 Commit by Bo-Yi Wu on `main`:\n``` update gitlab ci ```\n\n🌐 https://gitlab.com/appleboy/go-hello/-/pipelines/1234567",
+		},
+		plugin.Message(),
+	)
+}
+
+func TestLoadGitLabEnvMergeRequest(t *testing.T) {
+	plugin := Plugin{
+		Commit: Commit{Branch: "master"},
+		Build:  Build{Event: "push", Status: "success"},
+		Config: Config{Provider: providerGitLab},
+	}
+
+	plugin.loadGitLabEnv(mapEnv(map[string]string{
+		"CI_PROJECT_PATH":                     "appleboy/go-hello",
+		"CI_PROJECT_URL":                      "https://gitlab.com/appleboy/go-hello",
+		"CI_COMMIT_SHA":                       "9a2b8c1f0e4d5b6a7c8d9e0f1a2b3c4d5e6f7a8b",
+		"CI_COMMIT_REF_NAME":                  "feature/topics",
+		"CI_COMMIT_TITLE":                     "feat: support forum topics",
+		"CI_MERGE_REQUEST_IID":                "7",
+		"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+		"CI_PIPELINE_IID":                     "43",
+		"CI_PIPELINE_URL":                     "https://gitlab.com/appleboy/go-hello/-/pipelines/1234568",
+		"CI_PIPELINE_SOURCE":                  "merge_request_event",
+		"GITLAB_USER_NAME":                    "octocat",
+		"GITLAB_USER_EMAIL":                   "octocat@example.com",
+	}))
+
+	assert.Equal(t, "pull_request", plugin.Build.Event)
+	assert.Equal(t, "7", plugin.Build.PR)
+	assert.Equal(t, "main", plugin.Commit.Branch)
+	assert.Equal(t, "octocat", plugin.Commit.Author)
+	assert.Equal(t, "octocat@example.com", plugin.Commit.Email)
+	assert.Equal(t, "success", plugin.Build.Status)
+	assert.Equal(
+		t,
+		[]string{
+			"✅ Pipeline #43 of `appleboy/go-hello` success.\n\n🔀 MR !7 by octocat into `main`:\n``` feat: support forum topics ```\n\n🔗 https://gitlab.com/appleboy/go-hello/-/merge_requests/7\n🌐 https://gitlab.com/appleboy/go-hello/-/pipelines/1234568",
+		},
+		plugin.Message(),
+	)
+}
+
+func TestLoadGitLabEnvTagAndSchedule(t *testing.T) {
+	plugin := Plugin{}
+	plugin.loadGitLabEnv(mapEnv(map[string]string{
+		"CI_COMMIT_TAG":      "v1.2.0",
+		"CI_DEFAULT_BRANCH":  "main",
+		"CI_PIPELINE_SOURCE": "push",
+	}))
+	assert.Equal(t, "tag", plugin.Build.Event)
+	assert.Equal(t, "v1.2.0", plugin.Build.Tag)
+	assert.Equal(t, "main", plugin.Commit.Branch)
+
+	plugin = Plugin{}
+	plugin.loadGitLabEnv(mapEnv(map[string]string{
+		"CI_PIPELINE_SOURCE": "schedule",
+	}))
+	assert.Equal(t, "cron", plugin.Build.Event)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+)
+
+const defaultLang = "en"
+
+// Catalog holds the translated strings of the built-in messages. Every
+// message is a fmt format string, the arguments are listed next to each
+// field and can be reordered with explicit indexes such as %[2]s.
+type Catalog struct {
+	// Status maps a build status to the word shown in the message.
+	Status map[string]string `json:"status"`
+
+	Build     string `json:"build"`     // number, repo, status
+	Workflow  string `json:"workflow"`  // workflow, number, repo, status
+	Pipeline  string `json:"pipeline"`  // number, repo, status
+	Release   string `json:"release"`   // tag, repo, status
+	Deploy    string `json:"deploy"`    // number, repo, target, status
+	Rollback  string `json:"rollback"`  // number, repo, target, status
+	Scheduled string `json:"scheduled"` // number, repo, status
+	Took      string `json:"took"`      // status, duration
+
+	Commit       string `json:"commit"`        // author, branch
+	Tagged       string `json:"tagged"`        // author
+	PullRequest  string `json:"pull_request"`  // pr number, author, target branch
+	MergeRequest string `json:"merge_request"` // mr iid, author, target branch
+}
+
+var catalogs = map[string]Catalog{
+	"en": {
+		Status: map[string]string{
+			"success":   "success",
+			"failure":   "failure",
+			"cancelled": "cancelled",
+		},
+		Build:        "Build #%[1]d of %[2]s %[3]s.",
+		Workflow:     "Workflow %[1]s #%[2]d of %[3]s %[4]s.",
+		Pipeline:     "Pipeline #%[1]d of %[2]s %[3]s.",
+		Release:      "Release %[1]s of %[2]s %[3]s.",
+		Deploy:       "Deploy #%[1]d of %[2]s to %[3]s %[4]s.",
+		Rollback:     "Rollback #%[1]d of %[2]s to %[3]s %[4]s.",
+		Scheduled:    "Scheduled build #%[1]d of %[2]s %[3]s.",
+		Took:         "%[1]s (took %[2]s)",
+		Commit:       "Commit by %[1]s on %[2]s:",
+		Tagged:       "Tagged by %[1]s:",
+		PullRequest:  "PR #%[1]s by %[2]s into %[3]s:",
+		MergeRequest: "MR !%[1]s by %[2]s into %[3]s:",
+	},
+	"zh-cn": {
+		Status: map[string]string{
+			"success":   "成功",
+			"failure":   "失败",
+			"cancelled": "已取消",
+		},
+		Build:        "%[2]s 的构建 #%[1]d %[3]s。",
+		Workflow:     "%[3]s 的工作流 %[1]s #%[2]d %[4]s。",
+		Pipeline:     "%[2]s 的流水线 #%[1]d %[3]s。",
+		Release:      "%[2]s 发布 %[1]s %[3]s。",
+		Deploy:       "%[2]s 的部署 #%[1]d 到 %[3]s %[4]s。",
+		Rollback:     "%[2]s 的回滚 #%[1]d 到 %[3]s %[4]s。",
+		Scheduled:    "%[2]s 的定时构建 #%[1]d %[3]s。",
+		Took:         "%[1]s（耗时 %[2]s）",
+		Commit:       "%[1]s 在 %[2]s 上的提交：",
+		Tagged:       "%[1]s 创建的标签：",
+		PullRequest:  "%[2]s 的 PR #%[1]s，合并到 %[3]s：",
+		MergeRequest: "%[2]s 的合并请求 !%[1]s，合并到 %[3]s：",
+	},
+	"zh-tw": {
+		Status: map[string]string{
+			"success":   "成功",
+			"failure":   "失敗",
+			"cancelled": "已取消",
+		},
+		Build:        "%[2]s 的建置 #%[1]d %[3]s。",
+		Workflow:     "%[3]s 的工作流程 %[1]s #%[2]d %[4]s。",
+		Pipeline:     "%[2]s 的管線 #%[1]d %[3]s。",
+		Release:      "%[2]s 發布 %[1]s %[3]s。",
+		Deploy:       "%[2]s 的部署 #%[1]d 至 %[3]s %[4]s。",
+		Rollback:     "%[2]s 的回滾 #%[1]d 至 %[3]s %[4]s。",
+		Scheduled:    "%[2]s 的排程建置 #%[1]d %[3]s。",
+		Took:         "%[1]s（耗時 %[2]s）",
+		Commit:       "%[1]s 在 %[2]s 上的提交：",
+		Tagged:       "%[1]s 建立的標籤：",
+		PullRequest:  "%[2]s 的 PR #%[1]s，合併至 %[3]s：",
+		MergeRequest: "%[2]s 的合併請求 !%[1]s，合併至 %[3]s：",
+	},
+	"ru": {
+		Status: map[string]string{
+			"success":   "успешно",
+			"failure":   "ошибка",
+			"cancelled": "отменена",
+		},
+		Build:        "Сборка #%[1]d проекта %[2]s: %[3]s.",
+		Workflow:     "Workflow %[1]s #%[2]d проекта %[3]s: %[4]s.",
+		Pipeline:     "Пайплайн #%[1]d проекта %[2]s: %[3]s.",
+		Release:      "Релиз %[1]s проекта %[2]s: %[3]s.",
+		Deploy:       "Развёртывание #%[1]d проекта %[2]s в %[3]s: %[4]s.",
+		Rollback:     "Откат #%[1]d проекта %[2]s в %[3]s: %[4]s.",
+		Scheduled:    "Плановая сборка #%[1]d проекта %[2]s: %[3]s.",
+		Took:         "%[1]s (заняло %[2]s)",
+		Commit:       "Коммит от %[1]s в ветке %[2]s:",
+		Tagged:       "Тег создал %[1]s:",
+		PullRequest:  "PR #%[1]s от %[2]s в %[3]s:",
+		MergeRequest: "MR !%[1]s от %[2]s в %[3]s:",
+	},
+}
+
+// catalogAliases maps short language codes to a built-in catalog.
+var catalogAliases = map[string]string{
+	"zh":      "zh-cn",
+	"zh-hans": "zh-cn",
+	"zh-hant": "zh-tw",
+	"zh-hk":   "zh-tw",
+}
+
+// loadCatalog returns the built-in catalog for lang, overlaid with the
+// strings found in the JSON file. Missing strings fall back to the
+// built-in catalog, and an empty lang selects English.
+func loadCatalog(lang, file string) (Catalog, error) {
+	if len(lang) == 0 {
+		lang = defaultLang
+	}
+
+	key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(lang)), "_", "-")
+	if alias, ok := catalogAliases[key]; ok {
+		key = alias
+	}
+
+	base, ok := catalogs[key]
+	if !ok {
+		// en-US -> en, ru-RU -> ru
+		prefix, _, _ := strings.Cut(key, "-")
+		if base, ok = catalogs[prefix]; !ok {
+			return Catalog{}, fmt.Errorf("unsupported language '%s'", lang)
+		}
+	}
+
+	// never let a custom catalog modify the built-in status words
+	base.Status = maps.Clone(base.Status)
+
+	if len(file) == 0 {
+		return base, nil
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return Catalog{}, fmt.Errorf("unable to read language file '%s': %w", file, err)
+	}
+
+	if err := json.Unmarshal(content, &base); err != nil {
+		return Catalog{}, fmt.Errorf("unable to unmarshal language file '%s': %w", file, err)
+	}
+
+	return base, nil
+}
+
+// status returns the translated word for a build status, or the status
+// itself when the catalog does not know it.
+func (c Catalog) status(status string) string {
+	if word, ok := c.Status[strings.ToLower(status)]; ok {
+		return word
+	}
+
+	return status
+}
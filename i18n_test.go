@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogsComplete(t *testing.T) {
+	for lang, catalog := range catalogs {
+		v := reflect.ValueOf(catalog)
+		for i := range v.NumField() {
+			field := v.Type().Field(i)
+			assert.False(t, v.Field(i).IsZero(), "%s: %s is empty", lang, field.Name)
+		}
+		for _, status := range []string{"success", "failure", "cancelled"} {
+			assert.NotEmpty(t, catalog.Status[status], "%s: status %s is empty", lang, status)
+		}
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"", "en"},
+		{"en", "en"},
+		{"en-US", "en"},
+		{"zh", "zh-cn"},
+		{"zh_CN", "zh-cn"},
+		{"zh-TW", "zh-tw"},
+		{"zh-Hant", "zh-tw"},
+		{"ru", "ru"},
+		{"ru-RU", "ru"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			catalog, err := loadCatalog(tt.lang, "")
+			require.NoError(t, err)
+			assert.Equal(t, catalogs[tt.want], catalog)
+		})
+	}
+
+	_, err := loadCatalog("xx", "")
+	assert.Error(t, err)
+}
+
+func TestLoadCatalogFile(t *testing.T) {
+	catalog, err := loadCatalog("ru", "tests/lang.json")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Пайплайн #%[1]d проекта %[2]s: %[3]s.", catalog.Build)
+	assert.Equal(t, catalogs["ru"].Commit, catalog.Commit)
+	assert.Equal(t, "сломано", catalog.status("failure"))
+	assert.Equal(t, "успешно", catalog.status("success"))
+
+	// the built-in catalog is left untouched
+	assert.Equal(t, "ошибка", catalogs["ru"].status("failure"))
+
+	_, err = loadCatalog("ru", "tests/not_found.json")
+	assert.Error(t, err)
+
+	_, err = loadCatalog("ru", "tests/message.txt")
+	assert.Error(t, err)
+}
+
+func TestCatalogStatus(t *testing.T) {
+	catalog := catalogs["zh-tw"]
+
+	assert.Equal(t, "失敗", catalog.status("failure"))
+	assert.Equal(t, "成功", catalog.status("SUCCESS"))
+	assert.Equal(t, "killed", catalog.status("killed"))
+}
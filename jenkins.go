@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// loadJenkinsEnv fills the repository, commit and build information from
+// the Jenkins environment, including the variables of multibranch pipelines.
+// Jenkins does not expose the build result, pass it with the status setting.
+func (p *Plugin) loadJenkinsEnv(getenv func(string) string) {
+	fullName, repoURL := parseRepoURL(getenv("GIT_URL"))
+	setString(&p.Repo.FullName, fullName, getenv("JOB_NAME"))
+	if owner, name, ok := strings.Cut(fullName, "/"); ok {
+		p.Repo.Namespace, p.Repo.Name = owner, name
+	}
+
+	setString(&p.Commit.Sha, getenv("GIT_COMMIT"))
+	// GIT_BRANCH includes the remote name, e.g. origin/main
+	branch := getenv("GIT_BRANCH")
+	if _, after, ok := strings.Cut(branch, "/"); ok && strings.HasPrefix(branch, "origin/") {
+		branch = after
+	}
+	setString(&p.Commit.Branch, getenv("CHANGE_TARGET"), getenv("BRANCH_NAME"), branch)
+	setString(&p.Commit.Author, getenv("CHANGE_AUTHOR_DISPLAY_NAME"), getenv("CHANGE_AUTHOR"))
+	setString(&p.Commit.Email, getenv("CHANGE_AUTHOR_EMAIL"))
+	p.loadGitEnv(getenv)
+	setString(&p.Commit.Message, getenv("CHANGE_TITLE"))
+	if len(repoURL) > 0 && len(p.Commit.Sha) > 0 {
+		p.Commit.Link = repoURL + "/commit/" + p.Commit.Sha
+	}
+	setString(&p.Commit.Link, getenv("CHANGE_URL"))
+
+	if number, err := strconv.Atoi(getenv("BUILD_NUMBER")); err == nil {
+		p.Build.Number = number
+	}
+	setString(&p.Build.Link, getenv("RUN_DISPLAY_URL"), getenv("BUILD_URL"))
+	setString(&p.Build.Tag, getenv("TAG_NAME"))
+	setString(&p.Build.PR, getenv("CHANGE_ID"))
+
+	switch {
+	case len(p.Build.Tag) > 0:
+		p.Build.Event = "tag"
+	case len(p.Build.PR) > 0:
+		p.Build.Event = "pull_request"
+	}
+}
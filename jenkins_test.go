@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadJenkinsEnv(t *testing.T) {
+	plugin := Plugin{
+		Build:  Build{Event: "push", Status: "success"},
+		Config: Config{Provider: providerJenkins},
+	}
+
+	plugin.loadJenkinsEnv(mapEnv(map[string]string{
+		"JENKINS_URL":      "https://jenkins.example.com/",
+		"JOB_NAME":         "go-hello/master",
+		"BUILD_NUMBER":     "73",
+		"BUILD_URL":        "https://jenkins.example.com/job/go-hello/job/master/73/",
+		"GIT_URL":          "https://github.com/appleboy/go-hello.git",
+		"GIT_COMMIT":       "e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2",
+		"GIT_BRANCH":       "origin/master",
+		"GIT_AUTHOR_NAME":  "Bo-Yi Wu",
+		"GIT_AUTHOR_EMAIL": "appleboy.tw@gmail.com",
+	}))
+
+	assert.Equal(t, "appleboy/go-hello", plugin.Repo.FullName)
+	assert.Equal(t, "appleboy", plugin.Repo.Namespace)
+	assert.Equal(t, "go-hello", plugin.Repo.Name)
+	assert.Equal(t, "master", plugin.Commit.Branch)
+	assert.Equal(t, "Bo-Yi Wu", plugin.Commit.Author)
+	assert.Equal(t, "appleboy.tw@gmail.com", plugin.Commit.Email)
+	assert.Equal(t, "https://github.com/appleboy/go-hello/commit/e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2", plugin.Commit.Link)
+	assert.Equal(t, 73, plugin.Build.Number)
+	assert.Equal(t, "https://jenkins.example.com/job/go-hello/job/master/73/", plugin.Build.Link)
+	assert.Equal(t, "push", plugin.Build.Event)
+}
+
+func TestLoadJenkinsEnvMultibranch(t *testing.T) {
+	plugin := Plugin{}
+
+	plugin.loadJenkinsEnv(mapEnv(map[string]string{
+		"JOB_NAME":        "go-hello/PR-8",
+		"BRANCH_NAME":     "PR-8",
+		"CHANGE_ID":       "8",
+		"CHANGE_URL":      "https://github.com/appleboy/go-hello/pull/8",
+		"CHANGE_TITLE":    "feat: support forum topics",
+		"CHANGE_AUTHOR":   "appleboy",
+		"CHANGE_TARGET":   "master",
+		"RUN_DISPLAY_URL": "https://jenkins.example.com/job/go-hello/view/change-requests/job/PR-8/1/display/redirect",
+	}))
+
+	assert.Equal(t, "go-hello/PR-8", plugin.Repo.FullName)
+	assert.Equal(t, "master", plugin.Commit.Branch)
+	assert.Equal(t, "appleboy", plugin.Commit.Author)
+	assert.Equal(t, "feat: support forum topics", plugin.Commit.Message)
+	assert.Equal(t, "https://github.com/appleboy/go-hello/pull/8", plugin.Commit.Link)
+	assert.Equal(t, "pull_request", plugin.Build.Event)
+	assert.Equal(t, "8", plugin.Build.PR)
+
+	plugin = Plugin{}
+	plugin.loadJenkinsEnv(mapEnv(map[string]string{"TAG_NAME": "v1.0.0"}))
+	assert.Equal(t, "tag", plugin.Build.Event)
+	assert.Equal(t, "v1.0.0", plugin.Build.Tag)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+// logLevels maps the log_level setting onto the slog levels.
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// initLogger sets up the logger of the plugin, writing to stderr unless a
+// test set another output, once per run.
+func (p *Plugin) initLogger() error {
+	if p.logger != nil {
+		return nil
+	}
+
+	w := p.logOutput
+	if w == nil {
+		w = os.Stderr
+	}
+
+	logger, err := p.newLogger(w)
+	if err != nil {
+		return err
+	}
+	p.logger = logger
+
+	return nil
+}
+
+// newLogger returns a text or JSON logger that hides the secrets of the
+// configuration. Debug raises the level to debug.
+func (p *Plugin) newLogger(w io.Writer) (*slog.Logger, error) {
+	level := slog.LevelInfo
+	if len(p.Config.LogLevel) > 0 {
+		var ok bool
+		level, ok = logLevels[strings.ToLower(p.Config.LogLevel)]
+		if !ok {
+			return nil, fmt.Errorf(
+				"unsupported log level '%s', use debug, info, warn or error",
+				p.Config.LogLevel,
+			)
+		}
+	}
+	if p.Config.Debug {
+		level = slog.LevelDebug
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch strings.ToLower(p.Config.LogFormat) {
+	case "", logFormatText:
+		handler = slog.NewTextHandler(w, opts)
+	case logFormatJSON:
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("unsupported log format '%s', use text or json", p.Config.LogFormat)
+	}
+
+	return slog.New(&redactHandler{Handler: handler, secrets: p.secrets()}), nil
+}
+
+// secrets returns a replacer that hides the token, the proxy password and
+// the client key, or nil when none is set.
+func (p *Plugin) secrets() *strings.Replacer {
+	var pairs []string
+	if len(p.Config.Token) > 0 {
+		pairs = append(pairs, p.Config.Token, "<token>")
+	}
+
+	for _, proxy := range []string{p.Config.Proxy, p.Config.Socks5} {
+		u, err := url.Parse(proxy)
+		if err != nil || u.User == nil {
+			continue
+		}
+		if password, ok := u.User.Password(); ok && len(password) > 0 {
+			// url.Error and the proxy header may carry the escaped form
+			pairs = append(pairs,
+				password, "<redacted>",
+				url.QueryEscape(password), "<redacted>",
+			)
+		}
+	}
+
+	if strings.Contains(p.Config.ClientKey, "-----BEGIN ") {
+		pairs = append(pairs, p.Config.ClientKey, "<redacted>")
+	}
+
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	return strings.NewReplacer(pairs...)
+}
+
+// redactHandler hides secrets in the message and the attributes of every
+// record, the debug output of the bot library included.
+type redactHandler struct {
+	slog.Handler
+	secrets *strings.Replacer
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.secrets == nil {
+		return h.Handler.Handle(ctx, r)
+	}
+
+	redacted := slog.NewRecord(r.Time, r.Level, h.secrets.Replace(r.Message), r.PC)
+	r.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.redactAttr(attr))
+		return true
+	})
+
+	return h.Handler.Handle(ctx, redacted)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if h.secrets != nil {
+		redacted := make([]slog.Attr, 0, len(attrs))
+		for _, attr := range attrs {
+			redacted = append(redacted, h.redactAttr(attr))
+		}
+		attrs = redacted
+	}
+
+	return &redactHandler{Handler: h.Handler.WithAttrs(attrs), secrets: h.secrets}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{Handler: h.Handler.WithGroup(name), secrets: h.secrets}
+}
+
+// redactAttr hides secrets in string values and in the text of any other
+// value, like the request parameters the bot library logs.
+func (h *redactHandler) redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		attr.Value = slog.StringValue(h.secrets.Replace(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]slog.Attr, 0, len(group))
+		for _, a := range group {
+			redacted = append(redacted, h.redactAttr(a))
+		}
+		attr.Value = slog.GroupValue(redacted...)
+	case slog.KindAny:
+		text := fmt.Sprint(value.Any())
+		if replaced := h.secrets.Replace(text); replaced != text {
+			attr.Value = slog.StringValue(replaced)
+		}
+	}
+
+	return attr
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"strings"
+	_ "time/tzdata" // embed the time zone database for time.zone on minimal images
 
 	"github.com/joho/godotenv"
 	"github.com/urfave/cli"
@@ -29,7 +31,7 @@ func main() {
 	// flags. Treat empty values as unset so the plugin does not fail.
 	unsetEmptyEnv(
 		"PLUGIN_MESSAGE_THREAD_ID", "TELEGRAM_MESSAGE_THREAD_ID", "INPUT_MESSAGE_THREAD_ID",
-		"DRONE_BUILD_NUMBER",
+		"DRONE_BUILD_NUMBER", "GITHUB_RUN_NUMBER",
 		"DRONE_STAGE_STARTED",
 		"DRONE_BUILD_FINISHED",
 	)
@@ -39,12 +41,22 @@ func main() {
 	app.Usage = "telegram plugin"
 	app.Action = run
 	app.Version = Version
+	app.Commands = []cli.Command{
+		renderCommand,
+		chatsCommand,
+		checkCommand,
+	}
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
 			Name:   "token",
 			Usage:  "telegram token",
 			EnvVar: "PLUGIN_TOKEN,TELEGRAM_TOKEN,INPUT_TOKEN",
 		},
+		cli.StringFlag{
+			Name:   "token.file",
+			Usage:  "read the telegram token from file, e.g. a secret mount",
+			EnvVar: "PLUGIN_TOKEN_FILE,TELEGRAM_TOKEN_FILE,INPUT_TOKEN_FILE",
+		},
 		cli.StringSliceFlag{
 			Name:   "to",
 			Usage:  "telegram user",
@@ -65,6 +77,36 @@ func main() {
 			Usage:  "send telegram message from file",
 			EnvVar: "PLUGIN_MESSAGE_FILE,TELEGRAM_MESSAGE_FILE,INPUT_MESSAGE_FILE",
 		},
+		cli.StringFlag{
+			Name:   "message.success",
+			Usage:  "send telegram message when the build succeeds",
+			EnvVar: "PLUGIN_MESSAGE_SUCCESS,TELEGRAM_MESSAGE_SUCCESS,INPUT_MESSAGE_SUCCESS",
+		},
+		cli.StringFlag{
+			Name:   "message.success.file",
+			Usage:  "send telegram message from file when the build succeeds",
+			EnvVar: "PLUGIN_MESSAGE_SUCCESS_FILE,TELEGRAM_MESSAGE_SUCCESS_FILE,INPUT_MESSAGE_SUCCESS_FILE",
+		},
+		cli.StringFlag{
+			Name:   "message.failure",
+			Usage:  "send telegram message when the build fails",
+			EnvVar: "PLUGIN_MESSAGE_FAILURE,TELEGRAM_MESSAGE_FAILURE,INPUT_MESSAGE_FAILURE",
+		},
+		cli.StringFlag{
+			Name:   "message.failure.file",
+			Usage:  "send telegram message from file when the build fails",
+			EnvVar: "PLUGIN_MESSAGE_FAILURE_FILE,TELEGRAM_MESSAGE_FAILURE_FILE,INPUT_MESSAGE_FAILURE_FILE",
+		},
+		cli.StringFlag{
+			Name:   "message.cancelled",
+			Usage:  "send telegram message when the build is cancelled",
+			EnvVar: "PLUGIN_MESSAGE_CANCELLED,TELEGRAM_MESSAGE_CANCELLED,INPUT_MESSAGE_CANCELLED",
+		},
+		cli.StringFlag{
+			Name:   "message.cancelled.file",
+			Usage:  "send telegram message from file when the build is cancelled",
+			EnvVar: "PLUGIN_MESSAGE_CANCELLED_FILE,TELEGRAM_MESSAGE_CANCELLED_FILE,INPUT_MESSAGE_CANCELLED_FILE",
+		},
 		cli.StringFlag{
 			Name:   "template.vars",
 			Usage:  "additional template vars to be used in message, as JSON string",
@@ -120,6 +162,33 @@ func main() {
 			Usage:  "enable debug message",
 			EnvVar: "PLUGIN_DEBUG,DEBUG,INPUT_DEBUG",
 		},
+		cli.StringFlag{
+			Name:   "log.format",
+			Usage:  "log format: text or json",
+			EnvVar: "PLUGIN_LOG_FORMAT,TELEGRAM_LOG_FORMAT,INPUT_LOG_FORMAT",
+			Value:  logFormatText,
+		},
+		cli.StringFlag{
+			Name:   "log.level",
+			Usage:  "log level: debug, info, warn or error, debug enables debug",
+			EnvVar: "PLUGIN_LOG_LEVEL,TELEGRAM_LOG_LEVEL,INPUT_LOG_LEVEL",
+			Value:  "info",
+		},
+		cli.BoolFlag{
+			Name:   "trace.http",
+			Usage:  "log every request to telegram and its response, with the token redacted",
+			EnvVar: "PLUGIN_TRACE_HTTP,TELEGRAM_TRACE_HTTP,INPUT_TRACE_HTTP",
+		},
+		cli.BoolFlag{
+			Name:   "preflight",
+			Usage:  "check the token, recipients and permissions before sending",
+			EnvVar: "PLUGIN_PREFLIGHT,TELEGRAM_PREFLIGHT,INPUT_PREFLIGHT",
+		},
+		cli.BoolFlag{
+			Name:   "dry.run",
+			Usage:  "print the messages as JSON instead of sending them",
+			EnvVar: "PLUGIN_DRY_RUN,TELEGRAM_DRY_RUN,INPUT_DRY_RUN",
+		},
 		cli.BoolFlag{
 			Name:   "match.email",
 			Usage:  "send message when only match email",
@@ -141,6 +210,29 @@ func main() {
 			Usage:  "telegram message format (Markdown or HTML)",
 			EnvVar: "PLUGIN_FORMAT,FORMAT,INPUT_FORMAT",
 		},
+		cli.StringFlag{
+			Name:   "time.zone",
+			Usage:  "time zone used to format build times in templates (e.g. Asia/Taipei)",
+			Value:  "UTC",
+			EnvVar: "PLUGIN_TIME_ZONE,TELEGRAM_TIME_ZONE,INPUT_TIME_ZONE",
+		},
+		cli.StringFlag{
+			Name:   "time.layout",
+			Usage:  "Go time layout used to format build times in templates",
+			Value:  defaultTimeLayout,
+			EnvVar: "PLUGIN_TIME_LAYOUT,TELEGRAM_TIME_LAYOUT,INPUT_TIME_LAYOUT",
+		},
+		cli.StringFlag{
+			Name:   "lang",
+			Usage:  "language of the default message (en, zh-CN, zh-TW or ru)",
+			Value:  defaultLang,
+			EnvVar: "PLUGIN_LANG,TELEGRAM_LANG,INPUT_LANG",
+		},
+		cli.StringFlag{
+			Name:   "lang.file",
+			Usage:  "load translations of the default message from json file",
+			EnvVar: "PLUGIN_LANG_FILE,TELEGRAM_LANG_FILE,INPUT_LANG_FILE",
+		},
 		cli.StringFlag{
 			Name:   "repo",
 			Usage:  "repository owner and repository name",
@@ -170,7 +262,7 @@ func main() {
 			Name:   "commit.branch",
 			Value:  "master",
 			Usage:  "git commit branch",
-			EnvVar: "DRONE_COMMIT_BRANCH",
+			EnvVar: "DRONE_COMMIT_BRANCH,GITHUB_REF_NAME",
 		},
 		cli.StringFlag{
 			Name:   "commit.link",
@@ -206,13 +298,13 @@ func main() {
 		cli.IntFlag{
 			Name:   "build.number",
 			Usage:  "build number",
-			EnvVar: "DRONE_BUILD_NUMBER",
+			EnvVar: "DRONE_BUILD_NUMBER,GITHUB_RUN_NUMBER",
 		},
 		cli.StringFlag{
 			Name:   "build.status",
-			Usage:  "build status",
+			Usage:  "build status (success, failure or cancelled)",
 			Value:  "success",
-			EnvVar: "DRONE_BUILD_STATUS",
+			EnvVar: "PLUGIN_STATUS,TELEGRAM_STATUS,INPUT_STATUS,DRONE_BUILD_STATUS",
 		},
 		cli.StringFlag{
 			Name:   "build.link",
@@ -239,11 +331,27 @@ func main() {
 			Usage:  "build finished",
 			EnvVar: "DRONE_BUILD_FINISHED",
 		},
+		cli.StringFlag{
+			Name:   "provider",
+			Usage:  "CI provider: auto, drone, github, gitea, gitlab, woodpecker, jenkins, bitbucket or circleci.",
+			Value:  providerAuto,
+			EnvVar: "PLUGIN_PROVIDER,TELEGRAM_PROVIDER,INPUT_PROVIDER",
+		},
 		cli.BoolFlag{
 			Name:   "github",
-			Usage:  "Boolean value, indicates the runtime environment is GitHub Action.",
+			Usage:  "Deprecated, use provider github instead.",
 			EnvVar: "PLUGIN_GITHUB,GITHUB",
 		},
+		cli.StringFlag{
+			Name:   "result.file",
+			Usage:  "write the sent chat and message ids to a JSON file",
+			EnvVar: "PLUGIN_RESULT_FILE,TELEGRAM_RESULT_FILE,INPUT_RESULT_FILE",
+		},
+		cli.StringFlag{
+			Name:   "github.output",
+			Usage:  "The path of the GitHub Actions step output file.",
+			EnvVar: "GITHUB_OUTPUT",
+		},
 		cli.StringFlag{
 			Name:   "github.workflow",
 			Usage:  "The name of the workflow.",
@@ -264,6 +372,16 @@ func main() {
 			Usage:  "The path to a file that contains the payload of the event that triggered the workflow. Value: /github/workflow/event.json.",
 			EnvVar: "GITHUB_EVENT_PATH",
 		},
+		cli.StringFlag{
+			Name:   "github.server.url",
+			Usage:  "The URL of the GitHub server. Value: https://github.com.",
+			EnvVar: "GITHUB_SERVER_URL",
+		},
+		cli.StringFlag{
+			Name:   "github.run.id",
+			Usage:  "A unique number for each workflow run within a repository.",
+			EnvVar: "GITHUB_RUN_ID",
+		},
 		cli.StringFlag{
 			Name:   "github.workspace",
 			Usage:  "The GitHub workspace path. Value: /github/workspace.",
@@ -274,15 +392,66 @@ func main() {
 			Usage:  "Provides the target deployment environment for the running build. This value is only available to promotion and rollback pipelines.",
 			EnvVar: "DRONE_DEPLOY_TO",
 		},
+		cli.StringFlag{
+			Name:   "proxy",
+			Usage:  "http, https or socks5 proxy URL, optionally with credentials",
+			EnvVar: "PLUGIN_PROXY,TELEGRAM_PROXY,INPUT_PROXY",
+		},
+		cli.StringFlag{
+			Name:   "ca.cert",
+			Usage:  "CA certificate bundle, a file or PEM, trusted in addition to the system roots",
+			EnvVar: "PLUGIN_CA_CERT,TELEGRAM_CA_CERT,INPUT_CA_CERT",
+		},
+		cli.StringFlag{
+			Name:   "client.cert",
+			Usage:  "client certificate for mutual TLS, a file or PEM",
+			EnvVar: "PLUGIN_CLIENT_CERT,TELEGRAM_CLIENT_CERT,INPUT_CLIENT_CERT",
+		},
+		cli.StringFlag{
+			Name:   "client.key",
+			Usage:  "client key for mutual TLS, a file or PEM",
+			EnvVar: "PLUGIN_CLIENT_KEY,TELEGRAM_CLIENT_KEY,INPUT_CLIENT_KEY",
+		},
+		cli.StringFlag{
+			Name:   "tls.min.version",
+			Usage:  "minimum TLS version: 1.0, 1.1, 1.2 or 1.3",
+			EnvVar: "PLUGIN_TLS_MIN_VERSION,TELEGRAM_TLS_MIN_VERSION,INPUT_TLS_MIN_VERSION",
+		},
 		cli.StringFlag{
 			Name:   "socks5",
-			Usage:  "Socks5 proxy URL",
+			Usage:  "Socks5 proxy URL (deprecated, use proxy)",
 			EnvVar: "PLUGIN_SOCKS5,SOCKS5,INPUT_SOCKS5",
 		},
+		cli.StringFlag{
+			Name:   "config.file",
+			Usage:  "load named notification profiles from yaml file",
+			EnvVar: "PLUGIN_CONFIG_FILE,TELEGRAM_CONFIG_FILE,INPUT_CONFIG_FILE",
+		},
+		cli.StringSliceFlag{
+			Name:   "profile",
+			Usage:  "profiles of the config file to send through, all of them by default",
+			EnvVar: "PLUGIN_PROFILE,TELEGRAM_PROFILE,INPUT_PROFILE",
+		},
+		cli.StringFlag{
+			Name:   "api.url",
+			Usage:  "telegram bot api server URL, for a self-hosted bot api server",
+			EnvVar: "PLUGIN_API_URL,TELEGRAM_API_URL,INPUT_API_URL",
+		},
+		cli.DurationFlag{
+			Name:   "timeout",
+			Usage:  "timeout of every request to telegram, uploads included, none by default",
+			EnvVar: "PLUGIN_TIMEOUT,TELEGRAM_TIMEOUT,INPUT_TIMEOUT",
+		},
+		cli.DurationFlag{
+			Name:   "total.timeout",
+			Usage:  "timeout of the whole run, 0 for none",
+			EnvVar: "PLUGIN_TOTAL_TIMEOUT,TELEGRAM_TOTAL_TIMEOUT,INPUT_TOTAL_TIMEOUT",
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+		log.Println(err)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -298,6 +467,30 @@ func unsetEmptyEnv(keys ...string) {
 }
 
 func run(c *cli.Context) error {
+	plugin, err := newPlugin(c)
+	if err != nil {
+		return err
+	}
+
+	return forEachProfile(c, plugin, func(p *Plugin) error {
+		return p.Exec(context.Background())
+	})
+}
+
+// newPlugin builds the plugin from the global flags and the environment of
+// the CI provider.
+func newPlugin(c *cli.Context) (Plugin, error) {
+	// the github flag predates the provider setting
+	name := c.String("provider")
+	if c.Bool("github") && !c.IsSet("provider") {
+		name = providerGitHub
+	}
+
+	provider, err := resolveProvider(name, os.Getenv)
+	if err != nil {
+		return Plugin{}, err
+	}
+
 	plugin := Plugin{
 		GitHub: GitHub{
 			Workflow:  c.String("github.workflow"),
@@ -305,6 +498,8 @@ func run(c *cli.Context) error {
 			Action:    c.String("github.action"),
 			EventName: c.String("github.event.name"),
 			EventPath: c.String("github.event.path"),
+			ServerURL: c.String("github.server.url"),
+			RunID:     c.String("github.run.id"),
 		},
 		Repo: Repo{
 			FullName:  c.String("repo"),
@@ -334,12 +529,21 @@ func run(c *cli.Context) error {
 		},
 		Config: Config{
 			Token:            c.String("token"),
+			TokenFile:        c.String("token.file"),
 			Debug:            c.Bool("debug"),
+			LogFormat:        c.String("log.format"),
+			LogLevel:         c.String("log.level"),
+			TraceHTTP:        c.Bool("trace.http"),
+			DryRun:           c.Bool("dry.run"),
+			Preflight:        c.Bool("preflight"),
 			MatchEmail:       c.Bool("match.email"),
 			To:               c.StringSlice("to"),
 			MessageThreadID:  c.Int("message.thread.id"),
 			Message:          c.String("message"),
 			MessageFile:      c.String("message.file"),
+			MessageSuccess:   c.String("message.success"),
+			MessageFailure:   c.String("message.failure"),
+			MessageCancelled: c.String("message.cancelled"),
 			TemplateVars:     c.String("template.vars"),
 			TemplateVarsFile: c.String("template.vars.file"),
 			Photo:            c.StringSlice("photo"),
@@ -351,13 +555,38 @@ func run(c *cli.Context) error {
 			Video:            c.StringSlice("video"),
 			Venue:            c.StringSlice("venue"),
 			Format:           c.String("format"),
-			GitHub:           c.Bool("github"),
+			TimeZone:         c.String("time.zone"),
+			TimeLayout:       c.String("time.layout"),
+			Lang:             c.String("lang"),
+			LangFile:         c.String("lang.file"),
+			Provider:         provider,
+			ResultFile:       c.String("result.file"),
+			GitHubOutput:     c.String("github.output"),
+			Proxy:            c.String("proxy"),
+			CACert:           c.String("ca.cert"),
+			ClientCert:       c.String("client.cert"),
+			ClientKey:        c.String("client.key"),
+			TLSMinVersion:    c.String("tls.min.version"),
 			Socks5:           c.String("socks5"),
+			APIURL:           c.String("api.url"),
+			Timeout:          c.Duration("timeout"),
+			TotalTimeout:     c.Duration("total.timeout"),
+
+			MessageFileSuccess:   c.String("message.success.file"),
+			MessageFileFailure:   c.String("message.failure.file"),
+			MessageFileCancelled: c.String("message.cancelled.file"),
 
 			DisableWebPagePreview: c.Bool("disable.webpage.preview"),
 			DisableNotification:   c.Bool("disable.notification"),
 		},
 	}
 
-	return plugin.Exec()
+	plugin.loadProviderEnv(os.Getenv)
+
+	// an explicit status setting wins over the status reported by the CI
+	if c.IsSet("build.status") {
+		plugin.Build.Status = c.String("build.status")
+	}
+
+	return plugin, nil
 }
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// markup formats the fields of the built-in message for a parse mode.
+type markup struct {
+	text func(string) string
+	code func(string) string
+	pre  func(string) string
+}
+
+var (
+	markdownMarkup = markup{
+		text: func(s string) string { return s },
+		code: func(s string) string { return "`" + s + "`" },
+		pre:  func(s string) string { return "``` " + s + " ```" },
+	}
+
+	htmlMarkup = markup{
+		text: html.EscapeString,
+		code: func(s string) string { return "<code>" + html.EscapeString(s) + "</code>" },
+		pre:  func(s string) string { return "<pre>" + html.EscapeString(s) + "</pre>" },
+	}
+)
+
+// messages returns the catalog of the built-in messages, English unless
+// Exec loaded another language.
+func (p *Plugin) messages() Catalog {
+	if p.catalog != nil {
+		return *p.catalog
+	}
+
+	return catalogs[defaultLang]
+}
+
+// Message is plugin default message. It is written in HTML when the
+// format is HTML and in Markdown otherwise.
+func (p *Plugin) Message() []string {
+	msgs := p.messages()
+	m := markdownMarkup
+	if strings.EqualFold(p.Config.Format, formatHTML) {
+		m = htmlMarkup
+	}
+
+	icon := icons[strings.ToLower(p.Build.Status)]
+	status := msgs.status(p.Build.Status)
+	if len(p.Build.Duration) > 0 {
+		status = fmt.Sprintf(msgs.Took, status, p.Build.Duration)
+	}
+	repo := m.code(p.Repo.FullName)
+
+	// ✅  Build #106 of drone-telegram succeeded (took 4m12s).
+	//
+	// 📝 Commit by appleboy on master:
+	//  chore: update default template
+	//
+	// 🌐 https://cloud.drone.io/appleboy/drone-telegram/106
+	title := fmt.Sprintf(msgs.Build, p.Build.Number, repo, status)
+	pullRequest := msgs.PullRequest
+	switch p.Config.Provider {
+	case providerGitHub, providerGitea:
+		// ✅ Workflow `CI` #12 of `appleboy/drone-telegram` success.
+		title = fmt.Sprintf(msgs.Workflow, m.code(p.GitHub.Workflow), p.Build.Number, repo, status)
+	case providerGitLab:
+		// ✅ Pipeline #12 of `appleboy/drone-telegram` success.
+		title = fmt.Sprintf(msgs.Pipeline, p.Build.Number, repo, status)
+		pullRequest = msgs.MergeRequest
+	case providerWoodpecker, providerBitbucket:
+		title = fmt.Sprintf(msgs.Pipeline, p.Build.Number, repo, status)
+	}
+	commit := "📝 " + fmt.Sprintf(msgs.Commit, m.text(p.Commit.Author), m.code(p.Commit.Branch))
+	links := []string{"🌐 " + m.text(p.Build.Link)}
+
+	switch p.Build.Event {
+	case "tag":
+		// ✅ Release `v1.0.0` of `appleboy/drone-telegram` success.
+		//
+		// 🏷 Tagged by appleboy:
+		title = fmt.Sprintf(msgs.Release, m.code(p.Build.Tag), repo, status)
+		commit = "🏷 " + fmt.Sprintf(msgs.Tagged, m.text(p.Commit.Author))
+	case "pull_request":
+		// 🔀 PR #42 by appleboy into master:
+		//
+		// 🔗 https://github.com/appleboy/drone-telegram/pull/42
+		commit = "🔀 " + fmt.Sprintf(pullRequest,
+			m.text(p.Build.PR),
+			m.text(p.Commit.Author),
+			m.code(p.Commit.Branch),
+		)
+		if len(p.Commit.Link) > 0 {
+			links = append([]string{"🔗 " + m.text(p.Commit.Link)}, links...)
+		}
+	case "promote":
+		// ✅ Deploy #106 of `appleboy/drone-telegram` to `production` success.
+		title = fmt.Sprintf(msgs.Deploy, p.Build.Number, repo, m.code(p.Build.DeployTo), status)
+	case "rollback":
+		title = fmt.Sprintf(msgs.Rollback, p.Build.Number, repo, m.code(p.Build.DeployTo), status)
+	case "cron":
+		title = fmt.Sprintf(msgs.Scheduled, p.Build.Number, repo, status)
+	}
+
+	return []string{
+		fmt.Sprintf("%s %s\n\n%s\n%s\n\n%s",
+			icon,
+			title,
+			commit,
+			m.pre(p.Commit.Message),
+			strings.Join(links, "\n"),
+		),
+	}
+}
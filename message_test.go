@@ -0,0 +1,249 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultMessageFormat(t *testing.T) {
+	plugin := Plugin{
+		Repo: Repo{
+			FullName:  "appleboy/go-hello",
+			Name:      "go-hello",
+			Namespace: "appleboy",
+		},
+		Commit: Commit{
+			Sha:     "e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2",
+			Author:  "Bo-Yi Wu",
+			Branch:  "master",
+			Message: "update travis",
+		},
+		Build: Build{
+			Number: 101,
+			Status: "success",
+			Link:   "https://github.com/appleboy/go-hello",
+		},
+	}
+
+	message := plugin.Message()
+
+	assert.Equal(
+		t,
+		[]string{
+			"✅ Build #101 of `appleboy/go-hello` success.\n\n📝 Commit by Bo-Yi Wu on `master`:\n``` update travis ```\n\n🌐 https://github.com/appleboy/go-hello",
+		},
+		message,
+	)
+}
+
+func TestDefaultMessageFormatFromGitHub(t *testing.T) {
+	plugin := Plugin{
+		Config: Config{
+			Provider: providerGitHub,
+		},
+		Repo: Repo{
+			FullName:  "appleboy/go-hello",
+			Name:      "go-hello",
+			Namespace: "appleboy",
+		},
+		Commit: Commit{
+			Sha:    "e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2",
+			Ref:    "refs/heads/master",
+			Branch: "master",
+		},
+		Build: Build{
+			Number: 12,
+			Event:  "push",
+			Status: "failure",
+		},
+		GitHub: GitHub{
+			Workflow:  "test-workflow",
+			Action:    "send notification",
+			EventName: "push",
+			EventPath: "tests/github_event_push.json",
+			ServerURL: "https://github.com",
+			RunID:     "1658821493",
+		},
+	}
+
+	require.NoError(t, plugin.GitHub.loadEvent())
+	plugin.fillFromGitHub()
+
+	assert.Equal(
+		t,
+		[]string{
+			"❌ Workflow `test-workflow` #12 of `appleboy/go-hello` failure.\n\n📝 Commit by Bo-Yi Wu on `master`:\n``` chore: update default template ```\n\n🌐 https://github.com/appleboy/go-hello/actions/runs/1658821493",
+		},
+		plugin.Message(),
+	)
+}
+
+func TestDefaultMessageFormatByEvent(t *testing.T) {
+	plugin := Plugin{
+		Repo: Repo{
+			FullName:  "appleboy/go-hello",
+			Name:      "go-hello",
+			Namespace: "appleboy",
+		},
+		Commit: Commit{
+			Author:  "Bo-Yi Wu",
+			Branch:  "master",
+			Link:    "https://github.com/appleboy/go-hello/pull/42",
+			Message: "update travis",
+		},
+		Build: Build{
+			Number:   101,
+			Status:   "success",
+			Link:     "https://cloud.drone.io/appleboy/go-hello/101",
+			Tag:      "v1.0.0",
+			PR:       "42",
+			DeployTo: "production",
+		},
+	}
+
+	tests := []struct {
+		event string
+		want  string
+	}{
+		{
+			event: "push",
+			want:  "✅ Build #101 of `appleboy/go-hello` success.\n\n📝 Commit by Bo-Yi Wu on `master`:\n``` update travis ```\n\n🌐 https://cloud.drone.io/appleboy/go-hello/101",
+		},
+		{
+			event: "tag",
+			want:  "✅ Release `v1.0.0` of `appleboy/go-hello` success.\n\n🏷 Tagged by Bo-Yi Wu:\n``` update travis ```\n\n🌐 https://cloud.drone.io/appleboy/go-hello/101",
+		},
+		{
+			event: "pull_request",
+			want:  "✅ Build #101 of `appleboy/go-hello` success.\n\n🔀 PR #42 by Bo-Yi Wu into `master`:\n``` update travis ```\n\n🔗 https://github.com/appleboy/go-hello/pull/42\n🌐 https://cloud.drone.io/appleboy/go-hello/101",
+		},
+		{
+			event: "promote",
+			want:  "✅ Deploy #101 of `appleboy/go-hello` to `production` success.\n\n📝 Commit by Bo-Yi Wu on `master`:\n``` update travis ```\n\n🌐 https://cloud.drone.io/appleboy/go-hello/101",
+		},
+		{
+			event: "rollback",
+			want:  "✅ Rollback #101 of `appleboy/go-hello` to `production` success.\n\n📝 Commit by Bo-Yi Wu on `master`:\n``` update travis ```\n\n🌐 https://cloud.drone.io/appleboy/go-hello/101",
+		},
+		{
+			event: "cron",
+			want:  "✅ Scheduled build #101 of `appleboy/go-hello` success.\n\n📝 Commit by Bo-Yi Wu on `master`:\n``` update travis ```\n\n🌐 https://cloud.drone.io/appleboy/go-hello/101",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			plugin.Build.Event = tt.event
+			assert.Equal(t, []string{tt.want}, plugin.Message())
+		})
+	}
+}
+
+func TestDefaultMessageFormatWithDuration(t *testing.T) {
+	plugin := Plugin{
+		Repo: Repo{
+			FullName: "appleboy/go-hello",
+		},
+		Commit: Commit{
+			Author:  "Bo-Yi Wu",
+			Branch:  "master",
+			Message: "update travis",
+		},
+		Build: Build{
+			Number:   101,
+			Status:   "failure",
+			Link:     "https://github.com/appleboy/go-hello",
+			Started:  1700000000,
+			Finished: 1700000252,
+		},
+	}
+
+	require.NoError(t, plugin.formatBuildTimes())
+	assert.Equal(
+		t,
+		[]string{
+			"❌ Build #101 of `appleboy/go-hello` failure (took 4m12s).\n\n📝 Commit by Bo-Yi Wu on `master`:\n``` update travis ```\n\n🌐 https://github.com/appleboy/go-hello",
+		},
+		plugin.Message(),
+	)
+}
+
+func TestDefaultMessageFormatLocalized(t *testing.T) {
+	plugin := Plugin{
+		Repo: Repo{
+			FullName: "appleboy/go-hello",
+		},
+		Commit: Commit{
+			Author:  "Bo-Yi Wu",
+			Branch:  "master",
+			Message: "update travis",
+		},
+		Build: Build{
+			Number:   101,
+			Status:   "success",
+			Link:     "https://github.com/appleboy/go-hello",
+			Duration: "4m12s",
+		},
+	}
+
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{
+			lang: "zh-TW",
+			want: "✅ `appleboy/go-hello` 的建置 #101 成功（耗時 4m12s）。\n\n📝 Bo-Yi Wu 在 `master` 上的提交：\n``` update travis ```\n\n🌐 https://github.com/appleboy/go-hello",
+		},
+		{
+			lang: "ru",
+			want: "✅ Сборка #101 проекта `appleboy/go-hello`: успешно (заняло 4m12s).\n\n📝 Коммит от Bo-Yi Wu в ветке `master`:\n``` update travis ```\n\n🌐 https://github.com/appleboy/go-hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang, func(t *testing.T) {
+			catalog, err := loadCatalog(tt.lang, "")
+			require.NoError(t, err)
+			plugin.catalog = &catalog
+			assert.Equal(t, []string{tt.want}, plugin.Message())
+		})
+	}
+}
+
+func TestDefaultMessageFormatHTML(t *testing.T) {
+	plugin := Plugin{
+		Repo: Repo{
+			FullName: "appleboy/go-hello",
+		},
+		Commit: Commit{
+			Author:  "Bo-Yi <Wu>",
+			Branch:  "feature/a&b",
+			Link:    "https://github.com/appleboy/go-hello/pull/42",
+			Message: "fix `Message()` for <b>bold</b> & co",
+		},
+		Build: Build{
+			Number: 101,
+			Event:  "pull_request",
+			Status: "success",
+			PR:     "42",
+			Link:   "https://cloud.drone.io/appleboy/go-hello/101?a=1&b=2",
+		},
+		Config: Config{
+			Format: "html",
+		},
+	}
+
+	assert.Equal(
+		t,
+		[]string{
+			"✅ Build #101 of <code>appleboy/go-hello</code> success.\n\n" +
+				"🔀 PR #42 by Bo-Yi &lt;Wu&gt; into <code>feature/a&amp;b</code>:\n" +
+				"<pre>fix `Message()` for &lt;b&gt;bold&lt;/b&gt; &amp; co</pre>\n\n" +
+				"🔗 https://github.com/appleboy/go-hello/pull/42\n" +
+				"🌐 https://cloud.drone.io/appleboy/go-hello/101?a=1&amp;b=2",
+		},
+		plugin.Message(),
+	)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+)
+
+// migration returns the supergroup a failed send has to go to when its group
+// was upgraded, 0 otherwise.
+func migration(err error) int64 {
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) {
+		return tgErr.MigrateToChatID
+	}
+
+	return 0
+}
+
+// migrate records that a group was upgraded to a supergroup, so the rest of
+// the run sends there, and warns to update the configuration.
+func (p *Plugin) migrate(ctx context.Context, from, to int64) {
+	if p.migrated == nil {
+		p.migrated = map[int64]int64{}
+	}
+	p.migrated[from] = to
+
+	p.logger.LogAttrs(ctx, slog.LevelWarn,
+		"group was upgraded to a supergroup, replace the chat in the to setting",
+		slog.Int64("chat", from),
+		slog.Int64("new_chat", to),
+	)
+}
+
+// retarget returns the message addressed to the supergroup of its chat when
+// the group was upgraded during the run.
+func (p *Plugin) retarget(msg tgbotapi.Chattable) tgbotapi.Chattable {
+	to, ok := p.migrated[newPlanned(msg).ChatID]
+	if !ok {
+		return msg
+	}
+
+	switch m := msg.(type) {
+	case tgbotapi.MessageConfig:
+		m.ChatID = to
+		return m
+	case tgbotapi.PhotoConfig:
+		m.ChatID = to
+		return m
+	case tgbotapi.DocumentConfig:
+		m.ChatID = to
+		return m
+	case tgbotapi.StickerConfig:
+		m.ChatID = to
+		return m
+	case tgbotapi.AudioConfig:
+		m.ChatID = to
+		return m
+	case tgbotapi.VoiceConfig:
+		m.ChatID = to
+		return m
+	case tgbotapi.VideoConfig:
+		m.ChatID = to
+		return m
+	case tgbotapi.LocationConfig:
+		m.ChatID = to
+		return m
+	case tgbotapi.VenueConfig:
+		m.ChatID = to
+		return m
+	}
+
+	return msg
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateToSupergroup(t *testing.T) {
+	migrated := `{"ok":false,"error_code":400,` +
+		`"description":"Bad Request: group chat was upgraded to a supergroup chat",` +
+		`"parameters":{"migrate_to_chat_id":-1001234567890}}`
+	server := newBotServer(t, map[string]string{
+		"getMe":                       botResult(testBot),
+		"sendMessage:-4001":           migrated,
+		"sendDocument:-4001":          migrated,
+		"sendMessage:-1001234567890":  botResult(`{"message_id": 1, "chat": {"id": -1001234567890}}`),
+		"sendDocument:-1001234567890": botResult(`{"message_id": 2, "chat": {"id": -1001234567890}}`),
+		"sendMessage:1234567890":      botResult(`{"message_id": 3, "chat": {"id": 1234567890}}`),
+		"sendDocument:1234567890":     botResult(`{"message_id": 4, "chat": {"id": 1234567890}}`),
+	})
+
+	var buf bytes.Buffer
+	plugin := Plugin{
+		Config: Config{
+			Token:     "123456:ABC",
+			To:        []string{"-4001", "1234567890"},
+			Message:   "hello",
+			Document:  []string{"tests/gophercolor.png"},
+			APIURL:    server.URL,
+			LogFormat: logFormatJSON,
+		},
+		logOutput: &buf,
+	}
+
+	require.NoError(t, plugin.Exec(t.Context()))
+	assert.Equal(t, []Result{
+		{ChatID: -1001234567890, MessageID: 1, Kind: "message", Link: "https://t.me/c/1234567890/1"},
+		{ChatID: -1001234567890, MessageID: 2, Kind: "document", Link: "https://t.me/c/1234567890/2"},
+		{ChatID: 1234567890, MessageID: 3, Kind: "message"},
+		{ChatID: 1234567890, MessageID: 4, Kind: "document"},
+	}, plugin.results)
+
+	entries := logEntries(t, &buf)
+	var warnings []map[string]any
+	var attempts []float64
+	for _, entry := range entries {
+		if entry["level"] == "WARN" {
+			warnings = append(warnings, entry)
+		}
+		if entry["msg"] == "delivered" {
+			attempts = append(attempts, entry["attempt"].(float64))
+		}
+	}
+
+	// the document goes to the supergroup without another failure
+	require.Len(t, warnings, 2)
+	assert.Equal(t, "delivery failed", warnings[0]["msg"])
+	assert.Equal(t, "group was upgraded to a supergroup, replace the chat in the to setting", warnings[1]["msg"])
+	assert.InDelta(t, -4001, warnings[1]["chat"], 0)
+	assert.InDelta(t, -1001234567890, warnings[1]["new_chat"], 0)
+	assert.Equal(t, []float64{2, 1, 1, 1}, attempts)
+}
+
+func TestPreflightMigratedGroup(t *testing.T) {
+	migrated := `{"ok":false,"error_code":400,` +
+		`"description":"Bad Request: group chat was upgraded to a supergroup chat",` +
+		`"parameters":{"migrate_to_chat_id":-1001234567890}}`
+	server := newBotServer(t, map[string]string{
+		"getMe":                        botResult(testBot),
+		"getChat:-4001":                migrated,
+		"getChat:-1001234567890":       botResult(`{"id": -1001234567890, "type": "supergroup", "title": "Drone CI"}`),
+		"getChatMember:-1001234567890": botResult(`{"status": "administrator", "user": {"id": 999, "is_bot": true, "first_name": "drone"}}`),
+		"sendMessage:-4001":            botError(500, "the old group must not be used"),
+		"sendMessage:-1001234567890":   botResult(`{"message_id": 1, "chat": {"id": -1001234567890}}`),
+	})
+
+	var buf bytes.Buffer
+	plugin := Plugin{
+		Config: Config{
+			Token:     "123456:ABC",
+			To:        []string{"-4001"},
+			Message:   "hello",
+			APIURL:    server.URL,
+			Preflight: true,
+			LogFormat: logFormatJSON,
+		},
+		logOutput: &buf,
+	}
+
+	require.NoError(t, plugin.Exec(t.Context()))
+	assert.Equal(t, []Result{
+		{ChatID: -1001234567890, MessageID: 1, Kind: "message", Link: "https://t.me/c/1234567890/1"},
+	}, plugin.results)
+
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "group was upgraded to a supergroup, replace the chat in the to setting", entries[0]["msg"])
+	assert.Equal(t, "delivered", entries[1]["msg"])
+	assert.InDelta(t, 1, entries[1]["attempt"], 0)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+)
+
+// Planned describes a message a dry run would send.
+type Planned struct {
+	Profile   string  `json:"profile,omitempty"`
+	ChatID    int64   `json:"chat_id"`
+	ThreadID  int     `json:"message_thread_id,omitempty"`
+	Kind      string  `json:"kind"`
+	Text      string  `json:"text,omitempty"`
+	ParseMode string  `json:"parse_mode,omitempty"`
+	File      string  `json:"file,omitempty"`
+	Caption   string  `json:"caption,omitempty"`
+	Title     string  `json:"title,omitempty"`
+	Address   string  `json:"address,omitempty"`
+	Latitude  float64 `json:"latitude,omitempty"`
+	Longitude float64 `json:"longitude,omitempty"`
+
+	DisableWebPagePreview bool `json:"disable_web_page_preview,omitempty"`
+	DisableNotification   bool `json:"disable_notification,omitempty"`
+}
+
+// newPlanned describes the message a config would send.
+func newPlanned(msg tgbotapi.Chattable) Planned {
+	planned := Planned{Kind: messageKind(msg)}
+
+	var chat tgbotapi.BaseChat
+	var file tgbotapi.RequestFileData
+	switch m := msg.(type) {
+	case tgbotapi.MessageConfig:
+		chat = m.BaseChat
+		planned.Text = m.Text
+		planned.ParseMode = m.ParseMode
+		planned.DisableWebPagePreview = m.LinkPreviewOptions.IsDisabled
+	case tgbotapi.PhotoConfig:
+		chat, file = m.BaseChat, m.File
+	case tgbotapi.DocumentConfig:
+		chat, file = m.BaseChat, m.File
+	case tgbotapi.StickerConfig:
+		chat, file = m.BaseChat, m.File
+	case tgbotapi.AudioConfig:
+		chat, file = m.BaseChat, m.File
+		planned.Title = m.Title
+	case tgbotapi.VoiceConfig:
+		chat, file = m.BaseChat, m.File
+	case tgbotapi.VideoConfig:
+		chat, file = m.BaseChat, m.File
+		planned.Caption = m.Caption
+	case tgbotapi.LocationConfig:
+		chat = m.BaseChat
+		planned.Latitude, planned.Longitude = m.Latitude, m.Longitude
+	case tgbotapi.VenueConfig:
+		chat = m.BaseChat
+		planned.Latitude, planned.Longitude = m.Latitude, m.Longitude
+		planned.Title, planned.Address = m.Title, m.Address
+	}
+
+	planned.ChatID = chat.ChatID
+	planned.ThreadID = chat.MessageThreadID
+	planned.DisableNotification = chat.DisableNotification
+	if path, ok := file.(tgbotapi.FilePath); ok {
+		planned.File = string(path)
+	}
+
+	return planned
+}
+
+// printPlan writes the messages of a dry run as JSON.
+func (p *Plugin) printPlan(w io.Writer) error {
+	return printPlan(w, p.plan)
+}
+
+// printPlan writes planned messages as a JSON array.
+func printPlan(w io.Writer, plan []Planned) error {
+	if plan == nil {
+		plan = []Planned{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(plan)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	plugin := Plugin{
+		Repo:   Repo{FullName: "appleboy/go-hello"},
+		Commit: Commit{Author: "appleboy", Email: "appleboy.tw@gmail.com"},
+		Build:  Build{Number: 101, Status: "success"},
+		Config: Config{
+			To:              []string{"1234567890", "-100987654321:appleboy.tw@gmail.com", "111:someone@example.com"},
+			MatchEmail:      true,
+			MessageThreadID: 12,
+			Message:         "build {{build.number}} of {{repo.fullName}} {{build.status}}",
+			Format:          formatHTML,
+			Photo:           []string{"tests/github.png"},
+			Location:        []string{"24.9163213 121.1424972"},
+			Venue:           []string{"35.661777 139.704051 竹北體育館 新竹縣竹北市"},
+			DryRun:          true,
+		},
+	}
+
+	require.NoError(t, plugin.Exec(t.Context()))
+	assert.Empty(t, plugin.results)
+	assert.Equal(
+		t,
+		[]Planned{
+			{ChatID: -100987654321, ThreadID: 12, Kind: "message", Text: "build 101 of appleboy/go-hello success", ParseMode: formatHTML},
+			{ChatID: -100987654321, ThreadID: 12, Kind: "photo", File: "tests/github.png"},
+			{ChatID: -100987654321, ThreadID: 12, Kind: "location", Latitude: 24.9163213, Longitude: 121.1424972},
+			{ChatID: -100987654321, ThreadID: 12, Kind: "venue", Latitude: 35.661777, Longitude: 139.704051, Title: "竹北體育館", Address: "新竹縣竹北市"},
+		},
+		plugin.plan,
+	)
+
+	// template errors still fail a dry run
+	plugin.Config.Message = "{{#if}}"
+	assert.Error(t, plugin.Exec(t.Context()))
+}
+
+func TestNewPlanned(t *testing.T) {
+	msg := tgbotapi.NewMessage(1234567890, "hello")
+	msg.LinkPreviewOptions.IsDisabled = true
+	msg.DisableNotification = true
+	assert.Equal(
+		t,
+		Planned{ChatID: 1234567890, Kind: "message", Text: "hello", DisableWebPagePreview: true, DisableNotification: true},
+		newPlanned(msg),
+	)
+
+	audio := tgbotapi.NewAudio(1234567890, tgbotapi.FilePath("tests/audio.mp3"))
+	audio.Title = "Audio Message"
+	assert.Equal(
+		t,
+		Planned{ChatID: 1234567890, Kind: "audio", File: "tests/audio.mp3", Title: "Audio Message"},
+		newPlanned(audio),
+	)
+}
+
+func TestPrintPlan(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, (&Plugin{}).printPlan(&buf))
+	assert.Equal(t, "[]\n", buf.String())
+
+	buf.Reset()
+	plugin := Plugin{plan: []Planned{{ChatID: 1, Kind: "message", Text: "<b>ok</b>"}}}
+	require.NoError(t, plugin.printPlan(&buf))
+	assert.JSONEq(t, `[{"chat_id": 1, "kind": "message", "text": "<b>ok</b>"}]`, buf.String())
+	assert.Contains(t, buf.String(), "<b>ok</b>")
+}
@@ -1,18 +1,20 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"html"
-	"log"
+	"io"
+	"log/slog"
 	"maps"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	tgbotapi "github.com/OvyFlash/telegram-bot-api"
 	"github.com/appleboy/drone-template-lib/template"
@@ -21,6 +23,8 @@ import (
 const (
 	formatMarkdown = "Markdown"
 	formatHTML     = "HTML"
+
+	defaultTimeLayout = "2006-01-02 15:04:05 MST"
 )
 
 type (
@@ -31,49 +35,74 @@ type (
 		Action    string
 		EventName string
 		EventPath string
+		ServerURL string
+		RunID     string
+
+		// parsed from the file at EventPath
+		Event   GitHubEvent
+		Payload map[string]any
+	}
+
+	// CI information.
+	CI struct {
+		Provider string
 	}
 
 	// Repo information.
 	Repo struct {
-		FullName  string
-		Namespace string
-		Name      string
+		FullName  string `json:"full_name"`
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
 	}
 
 	// Commit information.
 	Commit struct {
-		Sha     string
-		Ref     string
-		Branch  string
-		Link    string
-		Author  string
-		Avatar  string
-		Email   string
-		Message string
+		Sha     string `json:"sha"`
+		Ref     string `json:"ref"`
+		Branch  string `json:"branch"`
+		Link    string `json:"link"`
+		Author  string `json:"author"`
+		Avatar  string `json:"avatar"`
+		Email   string `json:"email"`
+		Message string `json:"message"`
 	}
 
 	// Build information.
 	Build struct {
-		Tag      string
-		Event    string
-		Number   int
-		Status   string
-		Link     string
-		Started  int64
-		Finished int64
-		PR       string
-		DeployTo string
+		Tag      string `json:"tag"`
+		Event    string `json:"event"`
+		Number   int    `json:"number"`
+		Status   string `json:"status"`
+		Link     string `json:"link"`
+		Started  int64  `json:"started"`
+		Finished int64  `json:"finished"`
+		PR       string `json:"pr"`
+		DeployTo string `json:"deploy_to"`
+
+		// precomputed from Started and Finished for templates
+		Duration   string `json:"duration"`
+		StartedAt  string `json:"started_at" handlebars:"started_at"`
+		FinishedAt string `json:"finished_at" handlebars:"finished_at"`
 	}
 
 	// Config for the plugin.
 	Config struct {
 		Token            string
+		TokenFile        string
 		Debug            bool
 		MatchEmail       bool
 		To               []string
 		MessageThreadID  int
 		Message          string
 		MessageFile      string
+		MessageSuccess   string
+		MessageFailure   string
+		MessageCancelled string
+
+		MessageFileSuccess   string
+		MessageFileFailure   string
+		MessageFileCancelled string
+
 		TemplateVarsFile string
 		TemplateVars     string
 		Photo            []string
@@ -85,7 +114,26 @@ type (
 		Video            []string
 		Venue            []string
 		Format           string
-		GitHub           bool
+		TimeZone         string
+		TimeLayout       string
+		Lang             string
+		LangFile         string
+		Provider         string
+		ResultFile       string
+		GitHubOutput     string
+		DryRun           bool
+		Preflight        bool
+		APIURL           string
+		Proxy            string
+		CACert           string
+		ClientCert       string
+		ClientKey        string
+		TLSMinVersion    string
+		LogFormat        string
+		LogLevel         string
+		TraceHTTP        bool
+		Timeout          time.Duration
+		TotalTimeout     time.Duration
 		Socks5           string
 
 		DisableWebPagePreview bool
@@ -94,12 +142,22 @@ type (
 
 	// Plugin values.
 	Plugin struct {
-		GitHub GitHub
+		GitHub GitHub `handlebars:"github"`
+		CI     CI     `handlebars:"ci"`
 		Repo   Repo
 		Commit Commit
 		Build  Build
 		Config Config
 		Tpl    map[string]string
+
+		catalog   *Catalog
+		results   []Result
+		plan      []Planned
+		migrated  map[int64]int64
+		profile   string
+		gitErr    error
+		logger    *slog.Logger
+		logOutput io.Writer
 	}
 
 	// Location format
@@ -131,6 +189,33 @@ func trimElement(keys []string) []string {
 	return newKeys
 }
 
+// setString assigns the first non-empty value to dst and keeps dst
+// unchanged when every value is empty.
+func setString(dst *string, values ...string) {
+	for _, value := range values {
+		if len(value) > 0 {
+			*dst = value
+			return
+		}
+	}
+}
+
+// normalizeStatus maps the status names of the supported CI systems onto
+// success, failure and cancelled.
+func normalizeStatus(status string) string {
+	status = strings.ToLower(strings.TrimSpace(status))
+	switch status {
+	case "passed", "succeeded", "successful":
+		return "success"
+	case "failed", "error", "errored", "unstable":
+		return "failure"
+	case "canceled", "aborted", "killed", "stopped":
+		return "cancelled"
+	}
+
+	return status
+}
+
 func escapeMarkdown(keys []string) []string {
 	newKeys := make([]string, 0, len(keys))
 
@@ -158,14 +243,17 @@ func escapeMarkdownFields(fields ...*string) {
 	}
 }
 
-func globList(keys []string) []string {
+func (p *Plugin) globList(ctx context.Context, keys []string) []string {
 	newKeys := make([]string, 0, len(keys))
 
 	for _, pattern := range keys {
 		pattern = strings.TrimSpace(pattern)
 		matches, err := filepath.Glob(pattern)
 		if err != nil {
-			log.Printf("Glob error for %q: %s", pattern, err)
+			p.logger.LogAttrs(ctx, slog.LevelWarn, "ignoring an invalid file pattern",
+				slog.String("pattern", pattern),
+				slog.Any("error", err),
+			)
 			continue
 		}
 		newKeys = append(newKeys, matches...)
@@ -174,7 +262,7 @@ func globList(keys []string) []string {
 	return newKeys
 }
 
-func convertLocation(value string) (Location, bool) {
+func (p *Plugin) convertLocation(ctx context.Context, value string) (Location, bool) {
 	var latitude, longitude float64
 	var title, address string
 	var err error
@@ -193,14 +281,14 @@ func convertLocation(value string) (Location, bool) {
 	}
 
 	latitude, err = strconv.ParseFloat(values[0], 64)
-	if err != nil {
-		log.Println(err.Error())
-		return Location{}, true
+	if err == nil {
+		longitude, err = strconv.ParseFloat(values[1], 64)
 	}
-
-	longitude, err = strconv.ParseFloat(values[1], 64)
 	if err != nil {
-		log.Println(err.Error())
+		p.logger.LogAttrs(ctx, slog.LevelWarn, "ignoring an invalid location",
+			slog.String("location", value),
+			slog.Any("error", err),
+		)
 		return Location{}, true
 	}
 
@@ -257,30 +345,121 @@ func parseTo(to []string, authorEmail string, matchEmail bool) []int64 {
 	return ids
 }
 
-// Exec executes the plugin.
-func (p *Plugin) Exec() (err error) {
-	if len(p.Config.Token) == 0 || len(p.Config.To) == 0 {
-		return errors.New("missing telegram token or user list")
+// messageTemplate returns the message and message file configured for the
+// current build status, falling back to the generic message settings.
+func (p *Plugin) messageTemplate() (message, file string) {
+	switch strings.ToLower(p.Build.Status) {
+	case "success":
+		message, file = p.Config.MessageSuccess, p.Config.MessageFileSuccess
+	case "failure":
+		message, file = p.Config.MessageFailure, p.Config.MessageFileFailure
+	case "cancelled":
+		message, file = p.Config.MessageCancelled, p.Config.MessageFileCancelled
+	}
+
+	if len(message) > 0 || len(file) > 0 {
+		return message, file
+	}
+
+	return p.Config.Message, p.Config.MessageFile
+}
+
+// formatBuildTimes fills the build duration and the formatted start and
+// finish times. A running build is measured up to the current time.
+func (p *Plugin) formatBuildTimes() error {
+	if p.Build.Started <= 0 {
+		return nil
+	}
+
+	loc := time.UTC
+	if len(p.Config.TimeZone) > 0 {
+		var err error
+		loc, err = time.LoadLocation(p.Config.TimeZone)
+		if err != nil {
+			return fmt.Errorf("unable to load time zone '%s': %w", p.Config.TimeZone, err)
+		}
+	}
+
+	layout := p.Config.TimeLayout
+	if len(layout) == 0 {
+		layout = defaultTimeLayout
+	}
+
+	started := time.Unix(p.Build.Started, 0)
+	finished := time.Now()
+	if p.Build.Finished > 0 {
+		finished = time.Unix(p.Build.Finished, 0)
+		p.Build.FinishedAt = finished.In(loc).Format(layout)
+	}
+
+	p.Build.StartedAt = started.In(loc).Format(layout)
+	if finished.After(started) {
+		p.Build.Duration = finished.Sub(started).Truncate(time.Second).String()
+	}
+
+	return nil
+}
+
+// render returns the messages with the template data filled in and
+// escaped for the configured format, exactly as they are sent.
+func (p *Plugin) render() ([]string, error) {
+	// the render command renders without Exec
+	err := p.initLogger()
+	if err != nil {
+		return nil, err
 	}
 
+	p.Build.Status = normalizeStatus(p.Build.Status)
+
+	if err = p.formatBuildTimes(); err != nil {
+		return nil, err
+	}
+
+	p.CI.Provider = p.Config.Provider
+	if p.Config.Provider == providerGitHub || p.Config.Provider == providerGitea {
+		// the event only adds details, a broken one must not stop the message
+		if len(p.GitHub.EventPath) > 0 {
+			if err = p.GitHub.loadEvent(); err != nil {
+				p.logger.LogAttrs(context.Background(), slog.LevelWarn, "ignoring the event of the workflow",
+					slog.String("path", p.GitHub.EventPath),
+					slog.Any("error", err),
+				)
+			}
+		}
+		p.fillFromGitHub()
+	}
+
+	catalog, err := loadCatalog(p.Config.Lang, p.Config.LangFile)
+	if err != nil {
+		return nil, err
+	}
+	p.catalog = &catalog
+
 	var message []string
+	var builtin bool
+	messageText, messageFile := p.messageTemplate()
 	switch {
-	case len(p.Config.MessageFile) > 0:
-		message, err = loadTextFromFile(p.Config.MessageFile)
+	case len(messageFile) > 0:
+		message, err = loadTextFromFile(messageFile)
 		if err != nil {
-			return fmt.Errorf("error loading message file '%s': %w", p.Config.MessageFile, err)
+			return nil, fmt.Errorf("error loading message file '%s': %w", messageFile, err)
 		}
-	case len(p.Config.Message) > 0:
-		message = []string{p.Config.Message}
+	case len(messageText) > 0:
+		message = []string{messageText}
 	default:
-		p.Config.Format = formatMarkdown
+		if strings.EqualFold(p.Config.Format, formatHTML) {
+			p.Config.Format = formatHTML
+		} else {
+			p.Config.Format = formatMarkdown
+		}
 		message = p.Message()
+		builtin = true
 	}
 
 	if p.Config.TemplateVars != "" {
 		p.Tpl = make(map[string]string)
 		if err = json.Unmarshal([]byte(p.Config.TemplateVars), &p.Tpl); err != nil {
-			return fmt.Errorf(
+			return nil, fmt.Errorf(
 				"unable to unmarshal template vars from JSON string '%s': %w",
 				p.Config.TemplateVars,
 				err,
@@ -291,7 +470,7 @@ func (p *Plugin) Exec() (err error) {
 	if p.Config.TemplateVarsFile != "" {
 		content, err := os.ReadFile(p.Config.TemplateVarsFile)
 		if err != nil {
-			return fmt.Errorf(
+			return nil, fmt.Errorf(
 				"unable to read file with template vars '%s': %w",
 				p.Config.TemplateVarsFile,
 				err,
@@ -299,7 +478,7 @@ func (p *Plugin) Exec() (err error) {
 		}
 		vars := make(map[string]string)
 		if err = json.Unmarshal(content, &vars); err != nil {
-			return fmt.Errorf(
+			return nil, fmt.Errorf(
 				"unable to unmarshal template vars from JSON file '%s': %w",
 				p.Config.TemplateVarsFile,
 				err,
@@ -313,34 +492,6 @@ func (p *Plugin) Exec() (err error) {
 		}
 	}
 
-	var opts []tgbotapi.BotAPIOption
-	if len(p.Config.Socks5) > 0 {
-		var proxyURL *url.URL
-		proxyURL, err = url.Parse(p.Config.Socks5)
-		if err != nil {
-			return fmt.Errorf("unable to parse socks5 proxy URL '%s': %w", p.Config.Socks5, err)
-		}
-		proxyClient := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
-		opts = append(opts, tgbotapi.WithHTTPClient(proxyClient))
-	}
-
-	bot, err := tgbotapi.NewBotAPIWithOptions(p.Config.Token, opts...)
-	if err != nil {
-		return err
-	}
-
-	bot.Debug = p.Config.Debug
-
-	ids := parseTo(p.Config.To, p.Commit.Email, p.Config.MatchEmail)
-	photos := globList(p.Config.Photo)
-	documents := globList(p.Config.Document)
-	stickers := globList(p.Config.Sticker)
-	audios := globList(p.Config.Audio)
-	voices := globList(p.Config.Voice)
-	videos := globList(p.Config.Video)
-	locations := trimElement(p.Config.Location)
-	venues := trimElement(p.Config.Venue)
-
 	message = trimElement(message)
 
 	if p.Config.Format == formatMarkdown {
@@ -357,17 +508,112 @@ func (p *Plugin) Exec() (err error) {
 	// pre-render message templates (identical for all users)
 	var renderedMessages []string
 	for _, value := range message {
+		// the built-in message is already rendered and escaped
+		if builtin {
+			renderedMessages = append(renderedMessages, value)
+			continue
+		}
 		txt, err := template.RenderTrim(value, p)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		renderedMessages = append(renderedMessages, html.UnescapeString(txt))
 	}
 
+	return renderedMessages, nil
+}
+
+// Exec executes the plugin.
+func (p *Plugin) Exec(ctx context.Context) (err error) {
+	if err = p.loadTokenFile(); err != nil {
+		return err
+	}
+
+	// after the token file, so the logger knows the token
+	if err = p.initLogger(); err != nil {
+		return err
+	}
+
+	if p.gitErr != nil {
+		p.logger.LogAttrs(ctx, slog.LevelWarn,
+			"unable to read the commit author and message with git, "+
+				"pass GIT_AUTHOR_NAME, GIT_AUTHOR_EMAIL and GIT_COMMIT_MESSAGE instead",
+			slog.Any("error", p.gitErr),
+		)
+	}
+
+	if p.Config.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Config.TotalTimeout)
+		defer cancel()
+	}
+
+	// a dry run never contacts telegram, so it needs no token
+	if (len(p.Config.Token) == 0 && !p.Config.DryRun) || len(p.Config.To) == 0 {
+		return errors.New("missing telegram token or user list")
+	}
+
+	renderedMessages, err := p.render()
+	if err != nil {
+		return err
+	}
+
+	var (
+		bot   *tgbotapi.BotAPI
+		total int
+	)
+	if p.Config.DryRun {
+		// the plans of profiles are printed once by forEachProfile
+		p.plan = []Planned{}
+		defer func() {
+			if err == nil && len(p.profile) == 0 {
+				err = p.printPlan(os.Stdout)
+			}
+		}()
+	} else {
+		bot, err = p.newBot(ctx)
+		if err != nil {
+			return err
+		}
+
+		// preflight already records the upgraded groups
+		p.migrated = nil
+		if p.Config.Preflight {
+			if err = checkError(p.checkChats(ctx, bot)); err != nil {
+				return fmt.Errorf("preflight failed: %w", err)
+			}
+		}
+
+		// record what was sent, also when a later message fails; the
+		// results of profiles are written once by forEachProfile
+		p.results = []Result{}
+		defer func() {
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("timed out after delivering %d of %d messages: %w", len(p.results), total, err)
+			}
+			if len(p.profile) > 0 {
+				return
+			}
+			if werr := p.writeResults(); werr != nil && err == nil {
+				err = werr
+			}
+		}()
+	}
+
+	ids := parseTo(p.Config.To, p.Commit.Email, p.Config.MatchEmail)
+	photos := p.globList(ctx, p.Config.Photo)
+	documents := p.globList(ctx, p.Config.Document)
+	stickers := p.globList(ctx, p.Config.Sticker)
+	audios := p.globList(ctx, p.Config.Audio)
+	voices := p.globList(ctx, p.Config.Voice)
+	videos := p.globList(ctx, p.Config.Video)
+	locations := trimElement(p.Config.Location)
+	venues := trimElement(p.Config.Venue)
+
 	// pre-parse locations and venues (identical for all users)
 	var parsedLocations []Location
 	for _, value := range locations {
-		loc, empty := convertLocation(value)
+		loc, empty := p.convertLocation(ctx, value)
 		if !empty {
 			parsedLocations = append(parsedLocations, loc)
 		}
@@ -375,12 +621,16 @@ func (p *Plugin) Exec() (err error) {
 
 	var parsedVenues []Location
 	for _, value := range venues {
-		loc, empty := convertLocation(value)
+		loc, empty := p.convertLocation(ctx, value)
 		if !empty {
 			parsedVenues = append(parsedVenues, loc)
 		}
 	}
 
+	total = len(ids) * (len(renderedMessages) + len(photos) + len(documents) +
+		len(stickers) + len(audios) + len(voices) + len(videos) +
+		len(parsedLocations) + len(parsedVenues))
+
 	for _, user := range ids {
 		for _, txt := range renderedMessages {
 			msg := tgbotapi.NewMessage(user, txt)
@@ -388,7 +638,7 @@ func (p *Plugin) Exec() (err error) {
 			msg.ParseMode = p.Config.Format
 			msg.LinkPreviewOptions.IsDisabled = p.Config.DisableWebPagePreview
 			msg.DisableNotification = p.Config.DisableNotification
-			if err := p.Send(bot, msg); err != nil {
+			if err := p.Send(ctx, bot, msg); err != nil {
 				return err
 			}
 		}
@@ -396,7 +646,7 @@ func (p *Plugin) Exec() (err error) {
 		for _, value := range photos {
 			msg := tgbotapi.NewPhoto(user, tgbotapi.FilePath(value))
 			msg.MessageThreadID = p.Config.MessageThreadID
-			if err := p.Send(bot, msg); err != nil {
+			if err := p.Send(ctx, bot, msg); err != nil {
 				return err
 			}
 		}
@@ -404,7 +654,7 @@ func (p *Plugin) Exec() (err error) {
 		for _, value := range documents {
 			msg := tgbotapi.NewDocument(user, tgbotapi.FilePath(value))
 			msg.MessageThreadID = p.Config.MessageThreadID
-			if err := p.Send(bot, msg); err != nil {
+			if err := p.Send(ctx, bot, msg); err != nil {
 				return err
 			}
 		}
@@ -412,7 +662,7 @@ func (p *Plugin) Exec() (err error) {
 		for _, value := range stickers {
 			msg := tgbotapi.NewSticker(user, tgbotapi.FilePath(value))
 			msg.MessageThreadID = p.Config.MessageThreadID
-			if err := p.Send(bot, msg); err != nil {
+			if err := p.Send(ctx, bot, msg); err != nil {
 				return err
 			}
 		}
@@ -421,7 +671,7 @@ func (p *Plugin) Exec() (err error) {
 			msg := tgbotapi.NewAudio(user, tgbotapi.FilePath(value))
 			msg.MessageThreadID = p.Config.MessageThreadID
 			msg.Title = "Audio Message"
-			if err := p.Send(bot, msg); err != nil {
+			if err := p.Send(ctx, bot, msg); err != nil {
 				return err
 			}
 		}
@@ -429,7 +679,7 @@ func (p *Plugin) Exec() (err error) {
 		for _, value := range voices {
 			msg := tgbotapi.NewVoice(user, tgbotapi.FilePath(value))
 			msg.MessageThreadID = p.Config.MessageThreadID
-			if err := p.Send(bot, msg); err != nil {
+			if err := p.Send(ctx, bot, msg); err != nil {
 				return err
 			}
 		}
@@ -438,7 +688,7 @@ func (p *Plugin) Exec() (err error) {
 			msg := tgbotapi.NewVideo(user, tgbotapi.FilePath(value))
 			msg.MessageThreadID = p.Config.MessageThreadID
 			msg.Caption = "Video Message"
-			if err := p.Send(bot, msg); err != nil {
+			if err := p.Send(ctx, bot, msg); err != nil {
 				return err
 			}
 		}
@@ -446,7 +696,7 @@ func (p *Plugin) Exec() (err error) {
 		for _, loc := range parsedLocations {
 			msg := tgbotapi.NewLocation(user, loc.Latitude, loc.Longitude)
 			msg.MessageThreadID = p.Config.MessageThreadID
-			if err := p.Send(bot, msg); err != nil {
+			if err := p.Send(ctx, bot, msg); err != nil {
 				return err
 			}
 		}
@@ -460,7 +710,7 @@ func (p *Plugin) Exec() (err error) {
 				loc.Longitude,
 			)
 			msg.MessageThreadID = p.Config.MessageThreadID
-			if err := p.Send(bot, msg); err != nil {
+			if err := p.Send(ctx, bot, msg); err != nil {
 				return err
 			}
 		}
@@ -469,52 +719,136 @@ func (p *Plugin) Exec() (err error) {
 	return nil
 }
 
-// Send bot message.
-func (p *Plugin) Send(bot *tgbotapi.BotAPI, msg tgbotapi.Chattable) error {
-	message, err := bot.Send(msg)
+// loadTokenFile reads the token from the token file, e.g. a Docker or
+// Kubernetes secret mount, unless the token is set directly.
+func (p *Plugin) loadTokenFile() error {
+	if len(p.Config.Token) > 0 || len(p.Config.TokenFile) == 0 {
+		return nil
+	}
 
-	if p.Config.Debug {
-		log.Println("=====================")
-		log.Printf("Response Message: %#v\n", message)
-		log.Println("=====================")
+	content, err := os.ReadFile(p.Config.TokenFile)
+	if err != nil {
+		return fmt.Errorf("unable to read token file '%s': %w", p.Config.TokenFile, err)
 	}
 
-	if err == nil {
+	p.Config.Token = strings.TrimRightFunc(string(content), unicode.IsSpace)
+	if len(p.Config.Token) == 0 {
+		return fmt.Errorf("token file '%s' is empty", p.Config.TokenFile)
+	}
+
+	return nil
+}
+
+// apiEndpoint returns the Bot API endpoint format for a server address
+// such as http://localhost:8081, a full format is used as is.
+func apiEndpoint(apiURL string) string {
+	if strings.Contains(apiURL, "%s") {
+		return apiURL
+	}
+
+	return strings.TrimRight(apiURL, "/") + "/bot%s/%s"
+}
+
+// newBot returns a bot client for the configured token, server and proxy.
+func (p *Plugin) newBot(ctx context.Context) (*tgbotapi.BotAPI, error) {
+	if err := p.initLogger(); err != nil {
+		return nil, err
+	}
+
+	opts := []tgbotapi.BotAPIOption{tgbotapi.WithLogger(p.logger)}
+	if len(p.Config.APIURL) > 0 {
+		opts = append(opts, tgbotapi.WithAPIEndpoint(apiEndpoint(p.Config.APIURL)))
+	}
+
+	client, err := p.newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, tgbotapi.WithHTTPClient(contextClient{client: client, ctx: ctx}))
+
+	bot, err := tgbotapi.NewBotAPIWithOptions(p.Config.Token, opts...)
+	if err != nil {
+		return nil, p.apiError(err)
+	}
+
+	// the bot library logs requests and responses only in debug mode
+	bot.Debug = p.logger.Enabled(ctx, slog.LevelDebug)
+
+	return bot, nil
+}
+
+// Send bot message. When the group of the message was upgraded to a
+// supergroup, Send resends it there, and later messages go there directly.
+func (p *Plugin) Send(ctx context.Context, bot *tgbotapi.BotAPI, msg tgbotapi.Chattable) error {
+	if p.Config.DryRun {
+		planned := newPlanned(msg)
+		planned.Profile = p.profile
+		p.plan = append(p.plan, planned)
 		return nil
 	}
 
-	return errors.New(strings.ReplaceAll(err.Error(), p.Config.Token, "<token>"))
+	msg = p.retarget(msg)
+	err := p.send(ctx, bot, msg, 1)
+	if to := migration(err); to != 0 {
+		p.migrate(ctx, newPlanned(msg).ChatID, to)
+		return p.send(ctx, bot, p.retarget(msg), 2)
+	}
+
+	return err
 }
 
-// Message is plugin default message.
-func (p *Plugin) Message() []string {
-	icon := icons[strings.ToLower(p.Build.Status)]
-
-	if p.Config.GitHub {
-		return []string{fmt.Sprintf("%s/%s triggered by %s (%s)",
-			p.Repo.FullName,
-			p.GitHub.Workflow,
-			p.Repo.Namespace,
-			p.GitHub.EventName,
-		)}
-	}
-
-	// ✅  Build #106 of drone-telegram succeeded.
-	//
-	// 📝 Commit by appleboy on master:
-	//  chore: update default template
-	//
-	// 🌐 https://cloud.drone.io/appleboy/drone-telegram/106
-	return []string{
-		fmt.Sprintf("%s Build #%d of `%s` %s.\n\n📝 Commit by %s on `%s`:\n``` %s ```\n\n🌐 %s",
-			icon,
-			p.Build.Number,
-			p.Repo.FullName,
-			p.Build.Status,
-			p.Commit.Author,
-			p.Commit.Branch,
-			p.Commit.Message,
-			p.Build.Link,
-		),
+// send delivers a message and logs the attempt.
+func (p *Plugin) send(ctx context.Context, bot *tgbotapi.BotAPI, msg tgbotapi.Chattable, attempt int) error {
+	start := time.Now()
+	var message tgbotapi.Message
+	resp, err := bot.RequestWithContext(ctx, msg)
+	if err == nil {
+		err = json.Unmarshal(resp.Result, &message)
+	}
+
+	attrs := []slog.Attr{
+		slog.Int64("chat", newPlanned(msg).ChatID),
+		slog.String("kind", messageKind(msg)),
+		slog.Duration("latency", time.Since(start)),
+		slog.Int("attempt", attempt),
 	}
+
+	if err != nil {
+		// Send resends to the supergroup of an upgraded group
+		level := slog.LevelError
+		if migration(err) != 0 {
+			level = slog.LevelWarn
+		}
+		err = p.apiError(err)
+		p.logger.LogAttrs(ctx, level, "delivery failed", append(attrs, slog.Any("error", err))...)
+		return err
+	}
+
+	p.logger.LogAttrs(ctx, slog.LevelInfo, "delivered", append(attrs, slog.Int("message_id", message.MessageID))...)
+	result := newResult(messageKind(msg), message)
+	result.Profile = p.profile
+	p.results = append(p.results, result)
+
+	return nil
+}
+
+// redact hides the bot token, which is part of every API URL, in errors.
+func (p *Plugin) redact(err error) error {
+	secrets := p.secrets()
+	if err == nil || secrets == nil {
+		return err
+	}
+
+	return &redactedError{msg: secrets.Replace(err.Error()), err: err}
+}
+
+// redactedError hides the token in the message of an error and keeps the
+// error for errors.Is and errors.As.
+type redactedError struct {
+	msg string
+	err error
 }
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
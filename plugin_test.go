@@ -1,7 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -17,10 +25,42 @@ func skipIfNoTelegramSecrets(t *testing.T) {
 	}
 }
 
+// newBotServer starts a fake Bot API server. Responses are looked up by
+// "method:chat_id" first and by method next, unknown methods return 404.
+func newBotServer(t *testing.T, responses map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := path.Base(r.URL.Path)
+		response, ok := responses[method+":"+r.FormValue("chat_id")]
+		if !ok {
+			response, ok = responses[method]
+		}
+		if !ok {
+			response = botError(404, "Not Found")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// botResult returns a successful Bot API response.
+func botResult(result string) string {
+	return `{"ok":true,"result":` + result + `}`
+}
+
+// botError returns a failed Bot API response.
+func botError(code int, description string) string {
+	return fmt.Sprintf(`{"ok":false,"error_code":%d,"description":%q}`, code, description)
+}
+
 func TestMissingDefaultConfig(t *testing.T) {
 	var plugin Plugin
 
-	err := plugin.Exec()
+	err := plugin.Exec(t.Context())
 
 	assert.Error(t, err)
 }
@@ -32,66 +72,82 @@ func TestMissingUserConfig(t *testing.T) {
 		},
 	}
 
-	err := plugin.Exec()
+	err := plugin.Exec(t.Context())
 
 	assert.Error(t, err)
 }
 
-func TestDefaultMessageFormat(t *testing.T) {
+func TestMessageTemplate(t *testing.T) {
 	plugin := Plugin{
-		Repo: Repo{
-			FullName:  "appleboy/go-hello",
-			Name:      "go-hello",
-			Namespace: "appleboy",
-		},
-		Commit: Commit{
-			Sha:     "e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2",
-			Author:  "Bo-Yi Wu",
-			Branch:  "master",
-			Message: "update travis",
-		},
-		Build: Build{
-			Number: 101,
-			Status: "success",
-			Link:   "https://github.com/appleboy/go-hello",
+		Config: Config{
+			Message:            "generic",
+			MessageFile:        "tests/message.txt",
+			MessageSuccess:     "success",
+			MessageFailure:     "failure",
+			MessageFileFailure: "tests/message_failure.txt",
 		},
 	}
 
-	message := plugin.Message()
+	tests := []struct {
+		status      string
+		wantMessage string
+		wantFile    string
+	}{
+		{"success", "success", ""},
+		{"SUCCESS", "success", ""},
+		{"failure", "failure", "tests/message_failure.txt"},
+		{"cancelled", "generic", "tests/message.txt"},
+		{"killed", "generic", "tests/message.txt"},
+	}
 
-	assert.Equal(
-		t,
-		[]string{
-			"✅ Build #101 of `appleboy/go-hello` success.\n\n📝 Commit by Bo-Yi Wu on `master`:\n``` update travis ```\n\n🌐 https://github.com/appleboy/go-hello",
-		},
-		message,
-	)
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			plugin.Build.Status = tt.status
+			message, file := plugin.messageTemplate()
+			assert.Equal(t, tt.wantMessage, message)
+			assert.Equal(t, tt.wantFile, file)
+		})
+	}
+
+	plugin.Config.MessageCancelled = ""
+	plugin.Config.MessageFileCancelled = "tests/message_cancelled.txt"
+	plugin.Build.Status = "cancelled"
+	message, file := plugin.messageTemplate()
+	assert.Empty(t, message)
+	assert.Equal(t, "tests/message_cancelled.txt", file)
 }
 
-func TestDefaultMessageFormatFromGitHub(t *testing.T) {
+func TestFormatBuildTimes(t *testing.T) {
 	plugin := Plugin{
-		Config: Config{
-			GitHub: true,
-		},
-		Repo: Repo{
-			FullName:  "appleboy/go-hello",
-			Name:      "go-hello",
-			Namespace: "appleboy",
-		},
-		GitHub: GitHub{
-			Workflow:  "test-workflow",
-			Action:    "send notification",
-			EventName: "push",
+		Build: Build{
+			Started:  1700000000,
+			Finished: 1700000252,
 		},
 	}
 
-	message := plugin.Message()
+	require.NoError(t, plugin.formatBuildTimes())
+	assert.Equal(t, "4m12s", plugin.Build.Duration)
+	assert.Equal(t, "2023-11-14 22:13:20 UTC", plugin.Build.StartedAt)
+	assert.Equal(t, "2023-11-14 22:17:32 UTC", plugin.Build.FinishedAt)
+
+	plugin.Config.TimeZone = "Asia/Taipei"
+	plugin.Config.TimeLayout = time.RFC3339
+	require.NoError(t, plugin.formatBuildTimes())
+	assert.Equal(t, "2023-11-15T06:13:20+08:00", plugin.Build.StartedAt)
+	assert.Equal(t, "2023-11-15T06:17:32+08:00", plugin.Build.FinishedAt)
+
+	rendered, err := template.RenderTrim("took {{build.duration}} since {{build.started_at}}", plugin)
+	require.NoError(t, err)
+	assert.Equal(t, "took 4m12s since 2023-11-15T06:13:20+08:00", rendered)
+
+	plugin.Config.TimeZone = "Mars/Olympus"
+	assert.Error(t, plugin.formatBuildTimes())
 
-	assert.Equal(
-		t,
-		[]string{"appleboy/go-hello/test-workflow triggered by appleboy (push)"},
-		message,
-	)
+	// build not started yet
+	empty := Plugin{}
+	require.NoError(t, empty.formatBuildTimes())
+	assert.Empty(t, empty.Build.Duration)
+	assert.Empty(t, empty.Build.StartedAt)
 }
 
 func TestSendMessage(t *testing.T) {
@@ -140,17 +196,17 @@ func TestSendMessage(t *testing.T) {
 		},
 	}
 
-	err := plugin.Exec()
+	err := plugin.Exec(t.Context())
 	require.Error(t, err)
 
 	plugin.Config.Format = formatMarkdown
 	plugin.Config.Message = "Test escape under_score"
-	err = plugin.Exec()
+	err = plugin.Exec(t.Context())
 	require.Error(t, err)
 
 	// disable message
 	plugin.Config.Message = ""
-	err = plugin.Exec()
+	err = plugin.Exec(t.Context())
 	assert.Error(t, err)
 }
 
@@ -166,13 +222,13 @@ func TestDisableWebPagePreviewMessage(t *testing.T) {
 	}
 
 	plugin.Config.Message = "DisableWebPagePreview https://www.google.com.tw"
-	err := plugin.Exec()
+	err := plugin.Exec(t.Context())
 	require.NoError(t, err)
 
 	// disable message
 	plugin.Config.DisableWebPagePreview = false
 	plugin.Config.Message = "EnableWebPagePreview https://www.google.com.tw"
-	err = plugin.Exec()
+	err = plugin.Exec(t.Context())
 	assert.NoError(t, err)
 }
 
@@ -188,16 +244,149 @@ func TestDisableNotificationMessage(t *testing.T) {
 	}
 
 	plugin.Config.Message = "DisableNotification https://www.google.com.tw"
-	err := plugin.Exec()
+	err := plugin.Exec(t.Context())
 	require.NoError(t, err)
 
 	// disable message
 	plugin.Config.DisableNotification = false
 	plugin.Config.Message = "EnableNotification https://www.google.com.tw"
-	err = plugin.Exec()
+	err = plugin.Exec(t.Context())
 	assert.NoError(t, err)
 }
 
+func TestLoadTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "token")
+	require.NoError(t, os.WriteFile(file, []byte("123456:ABC-DEF\n \t\n"), 0o600))
+
+	plugin := Plugin{Config: Config{TokenFile: file}}
+	require.NoError(t, plugin.loadTokenFile())
+	assert.Equal(t, "123456:ABC-DEF", plugin.Config.Token)
+
+	// a token set directly wins over the file
+	plugin = Plugin{Config: Config{Token: "654321:XYZ", TokenFile: file}}
+	require.NoError(t, plugin.loadTokenFile())
+	assert.Equal(t, "654321:XYZ", plugin.Config.Token)
+
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(empty, []byte("\n"), 0o600))
+	plugin = Plugin{Config: Config{TokenFile: empty}}
+	assert.EqualError(t, plugin.loadTokenFile(), "token file '"+empty+"' is empty")
+
+	plugin = Plugin{Config: Config{TokenFile: filepath.Join(dir, "missing")}}
+	assert.ErrorContains(t, plugin.loadTokenFile(), "unable to read token file")
+}
+
+func TestTokenFileRedacted(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(file, []byte("123456:ABC-DEF\n"), 0o600))
+
+	// nothing listens on the server after it is closed
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	plugin := Plugin{
+		Config: Config{
+			TokenFile: file,
+			To:        []string{"1234567890"},
+			Message:   "hello",
+			APIURL:    server.URL,
+		},
+	}
+
+	err := plugin.Exec(t.Context())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/bot<token>/getMe")
+	assert.NotContains(t, err.Error(), "123456:ABC-DEF")
+}
+
+// newSlowBotServer starts a fake Bot API server that answers getMe and the
+// messages to fast, and stalls every other request until it is canceled.
+func newSlowBotServer(t *testing.T, fast string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := botResult(`{"message_id": 1, "chat": {"id": ` + fast + `}}`)
+		switch {
+		case path.Base(r.URL.Path) == "getMe":
+			response = botResult(`{"id": 999, "is_bot": true, "username": "drone_bot"}`)
+		case r.FormValue("chat_id") != fast:
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := newSlowBotServer(t, "1")
+
+	plugin := Plugin{
+		Config: Config{
+			Token:   "123456:ABC",
+			To:      []string{"2"},
+			Message: "hello",
+			APIURL:  server.URL,
+			Timeout: 100 * time.Millisecond,
+		},
+	}
+
+	start := time.Now()
+	err := plugin.Exec(t.Context())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Client.Timeout exceeded")
+	assert.NotContains(t, err.Error(), "123456:ABC")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestTotalTimeout(t *testing.T) {
+	server := newSlowBotServer(t, "1")
+
+	plugin := Plugin{
+		Config: Config{
+			Token:        "123456:ABC",
+			To:           []string{"1", "2"},
+			Message:      "hello",
+			APIURL:       server.URL,
+			ResultFile:   filepath.Join(t.TempDir(), "result.json"),
+			TotalTimeout: 200 * time.Millisecond,
+		},
+	}
+
+	err := plugin.Exec(t.Context())
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "timed out after delivering 1 of 2 messages")
+	assert.NotContains(t, err.Error(), "123456:ABC")
+
+	content, err := os.ReadFile(plugin.Config.ResultFile)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"chat_id": 1, "message_id": 1, "kind": "message"}]`, string(content))
+}
+
+func TestNewBotContext(t *testing.T) {
+	server := newSlowBotServer(t, "1")
+
+	plugin := Plugin{Config: Config{Token: "123456:ABC", APIURL: server.URL}}
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := plugin.newBot(ctx)
+	require.NoError(t, err)
+
+	// getMe takes no context, the client has to bind it
+	<-ctx.Done()
+	_, err = plugin.newBot(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
 func TestBotError(t *testing.T) {
 	plugin := Plugin{
 		Repo: Repo{
@@ -223,7 +412,7 @@ func TestBotError(t *testing.T) {
 		},
 	}
 
-	err := plugin.Exec()
+	err := plugin.Exec(t.Context())
 	assert.Error(t, err)
 }
 
@@ -241,6 +430,35 @@ func TestTrimElement(t *testing.T) {
 	assert.Equal(t, result, trimElement(input))
 }
 
+func TestSetString(t *testing.T) {
+	value := "master"
+
+	setString(&value, "", "")
+	assert.Equal(t, "master", value)
+
+	setString(&value, "", "main", "develop")
+	assert.Equal(t, "main", value)
+}
+
+func TestNormalizeStatus(t *testing.T) {
+	tests := map[string]string{
+		"success":   "success",
+		"SUCCESS":   "success",
+		"passed":    "success",
+		"failure":   "failure",
+		"failed":    "failure",
+		"UNSTABLE":  "failure",
+		"cancelled": "cancelled",
+		"canceled":  "cancelled",
+		"ABORTED":   "cancelled",
+		"running":   "running",
+	}
+
+	for status, want := range tests {
+		assert.Equal(t, want, normalizeStatus(status), status)
+	}
+}
+
 func TestEscapeMarkdown(t *testing.T) {
 	provider := [][][]string{
 		{
@@ -304,45 +522,57 @@ func TestParseTo(t *testing.T) {
 }
 
 func TestGlobList(t *testing.T) {
+	plugin := Plugin{logOutput: io.Discard}
+	require.NoError(t, plugin.initLogger())
+
 	var input []string
 	var result []string
 
 	input = []string{"tests/gophercolor.png", "測試", "3"}
 	result = []string{"tests/gophercolor.png"}
-	assert.Equal(t, result, globList(input))
+	assert.Equal(t, result, plugin.globList(t.Context(), input))
 
 	input = []string{"tests/*.mp3"}
 	result = []string{"tests/audio.mp3"}
-	assert.Equal(t, result, globList(input))
+	assert.Equal(t, result, plugin.globList(t.Context(), input))
 }
 
 func TestConvertLocation(t *testing.T) {
+	var buf bytes.Buffer
+	plugin := Plugin{Config: Config{LogFormat: logFormatJSON}, logOutput: &buf}
+	require.NoError(t, plugin.initLogger())
+
 	var input string
 	var result Location
 	var empty bool
 
 	input = "1"
-	result, empty = convertLocation(input)
+	result, empty = plugin.convertLocation(t.Context(), input)
 
 	assert.True(t, empty)
 	assert.Equal(t, Location{}, result)
 
 	// strconv.ParseInt: parsing "測試": invalid syntax
 	input = "測試 139.704051"
-	result, empty = convertLocation(input)
+	result, empty = plugin.convertLocation(t.Context(), input)
 
 	assert.True(t, empty)
 	assert.Equal(t, Location{}, result)
 
 	// strconv.ParseInt: parsing "測試": invalid syntax
 	input = "35.661777 測試"
-	result, empty = convertLocation(input)
+	result, empty = plugin.convertLocation(t.Context(), input)
 
 	assert.True(t, empty)
 	assert.Equal(t, Location{}, result)
 
+	entries := logEntries(t, &buf)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "ignoring an invalid location", entries[0]["msg"])
+	assert.Equal(t, "35.661777 測試", entries[1]["location"])
+
 	input = "35.661777 139.704051"
-	result, empty = convertLocation(input)
+	result, empty = plugin.convertLocation(t.Context(), input)
 
 	assert.False(t, empty)
 	assert.Equal(t, Location{
@@ -351,7 +581,7 @@ func TestConvertLocation(t *testing.T) {
 	}, result)
 
 	input = "35.661777 139.704051 title"
-	result, empty = convertLocation(input)
+	result, empty = plugin.convertLocation(t.Context(), input)
 
 	assert.False(t, empty)
 	assert.Equal(t, Location{
@@ -362,7 +592,7 @@ func TestConvertLocation(t *testing.T) {
 	}, result)
 
 	input = "35.661777 139.704051 title address"
-	result, empty = convertLocation(input)
+	result, empty = plugin.convertLocation(t.Context(), input)
 
 	assert.False(t, empty)
 	assert.Equal(t, Location{
@@ -405,10 +635,10 @@ Test HTML Format
 		},
 	}
 
-	assert.NoError(t, plugin.Exec())
+	assert.NoError(t, plugin.Exec(t.Context()))
 
 	plugin.Config.MessageFile = "tests/message_html.txt"
-	assert.NoError(t, plugin.Exec())
+	assert.NoError(t, plugin.Exec(t.Context()))
 }
 
 func TestMessageFile(t *testing.T) {
@@ -439,7 +669,7 @@ func TestMessageFile(t *testing.T) {
 		},
 	}
 
-	err := plugin.Exec()
+	err := plugin.Exec(t.Context())
 	assert.NoError(t, err)
 }
 
@@ -473,7 +703,7 @@ func TestTemplateVars(t *testing.T) {
 		},
 	}
 
-	err := plugin.Exec()
+	err := plugin.Exec(t.Context())
 	assert.NoError(t, err)
 }
 
@@ -505,7 +735,7 @@ func TestTemplateVarsFile(t *testing.T) {
 		},
 	}
 
-	err := plugin.Exec()
+	err := plugin.Exec(t.Context())
 	assert.NoError(t, err)
 }
 
@@ -539,7 +769,7 @@ func TestProxySendMessage(t *testing.T) {
 		},
 	}
 
-	err := plugin.Exec()
+	err := plugin.Exec(t.Context())
 	assert.NoError(t, err)
 }
 
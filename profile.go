@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+	"go.yaml.in/yaml/v3"
+)
+
+type (
+	// Profile holds the settings of a named notification profile. The keys
+	// match the plugin settings, and only the keys present in the file
+	// replace the values of the flags.
+	Profile struct {
+		Name string `yaml:"-"`
+
+		Token            string            `yaml:"token"`
+		TokenFile        string            `yaml:"token_file"`
+		To               stringList        `yaml:"to"`
+		MessageThreadID  int               `yaml:"message_thread_id"`
+		Message          string            `yaml:"message"`
+		MessageFile      string            `yaml:"message_file"`
+		MessageSuccess   string            `yaml:"message_success"`
+		MessageFailure   string            `yaml:"message_failure"`
+		MessageCancelled string            `yaml:"message_cancelled"`
+		TemplateVars     map[string]string `yaml:"template_vars"`
+		TemplateVarsFile string            `yaml:"template_vars_file"`
+		Photo            stringList        `yaml:"photo"`
+		Document         stringList        `yaml:"document"`
+		Sticker          stringList        `yaml:"sticker"`
+		Audio            stringList        `yaml:"audio"`
+		Voice            stringList        `yaml:"voice"`
+		Location         stringList        `yaml:"location"`
+		Video            stringList        `yaml:"video"`
+		Venue            stringList        `yaml:"venue"`
+		Format           string            `yaml:"format"`
+		Lang             string            `yaml:"lang"`
+		LangFile         string            `yaml:"lang_file"`
+		TimeZone         string            `yaml:"time_zone"`
+		TimeLayout       string            `yaml:"time_layout"`
+		ResultFile       string            `yaml:"result_file"`
+		APIURL           string            `yaml:"api_url"`
+		Proxy            string            `yaml:"proxy"`
+		CACert           string            `yaml:"ca_cert"`
+		ClientCert       string            `yaml:"client_cert"`
+		ClientKey        string            `yaml:"client_key"`
+		TLSMinVersion    string            `yaml:"tls_min_version"`
+		LogFormat        string            `yaml:"log_format"`
+		LogLevel         string            `yaml:"log_level"`
+		Timeout          time.Duration     `yaml:"timeout"`
+		TotalTimeout     time.Duration     `yaml:"total_timeout"`
+		Socks5           string            `yaml:"socks5"`
+
+		MessageFileSuccess   string `yaml:"message_success_file"`
+		MessageFileFailure   string `yaml:"message_failure_file"`
+		MessageFileCancelled string `yaml:"message_cancelled_file"`
+
+		MatchEmail            *bool `yaml:"only_match_email"`
+		DisableWebPagePreview *bool `yaml:"disable_web_page_preview"`
+		DisableNotification   *bool `yaml:"disable_notification"`
+	}
+
+	// stringList accepts a single string or a list of strings.
+	stringList []string
+)
+
+// UnmarshalYAML decodes a scalar as a list with one element.
+func (l *stringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = stringList{node.Value}
+		return nil
+	}
+
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+
+	return nil
+}
+
+// loadProfiles reads the profiles of a config file in the order they are
+// defined.
+func loadProfiles(file string) ([]Profile, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file '%s': %w", file, err)
+	}
+
+	// reject unknown settings, a typo would silently send to the wrong chat
+	var strict struct {
+		Profiles map[string]Profile `yaml:"profiles"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+	if err := dec.Decode(&strict); err != nil {
+		return nil, fmt.Errorf("unable to parse config file '%s': %w", file, err)
+	}
+
+	var ordered struct {
+		Profiles yaml.Node `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(content, &ordered); err != nil {
+		return nil, fmt.Errorf("unable to parse config file '%s': %w", file, err)
+	}
+
+	nodes := ordered.Profiles.Content
+	if ordered.Profiles.Kind != yaml.MappingNode || len(nodes) == 0 {
+		return nil, fmt.Errorf("config file '%s' has no profiles", file)
+	}
+
+	profiles := make([]Profile, 0, len(nodes)/2)
+	for i := 0; i+1 < len(nodes); i += 2 {
+		profile := strict.Profiles[nodes[i].Value]
+		profile.Name = nodes[i].Value
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
+
+// selectProfiles returns the named profiles, or all of them when no name
+// is given.
+func selectProfiles(profiles []Profile, names []string) ([]Profile, error) {
+	if len(names) == 0 {
+		return profiles, nil
+	}
+
+	selected := make([]Profile, 0, len(names))
+	for _, name := range names {
+		i := slices.IndexFunc(profiles, func(p Profile) bool { return p.Name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown profile '%s'", name)
+		}
+		selected = append(selected, profiles[i])
+	}
+
+	return selected, nil
+}
+
+// apply copies the settings of the profile to the config, except for the
+// settings whose flag is set explicitly.
+func (pr Profile) apply(cfg *Config, isSet func(name string) bool) {
+	set := func(flag string, present bool, apply func()) {
+		if present && !isSet(flag) {
+			apply()
+		}
+	}
+
+	set("token", len(pr.Token) > 0, func() { cfg.Token = pr.Token })
+	set("token.file", len(pr.TokenFile) > 0, func() { cfg.TokenFile = pr.TokenFile })
+	set("to", len(pr.To) > 0, func() { cfg.To = pr.To })
+	set("message.thread.id", pr.MessageThreadID != 0, func() { cfg.MessageThreadID = pr.MessageThreadID })
+	set("message", len(pr.Message) > 0, func() { cfg.Message = pr.Message })
+	set("message.file", len(pr.MessageFile) > 0, func() { cfg.MessageFile = pr.MessageFile })
+	set("message.success", len(pr.MessageSuccess) > 0, func() { cfg.MessageSuccess = pr.MessageSuccess })
+	set("message.failure", len(pr.MessageFailure) > 0, func() { cfg.MessageFailure = pr.MessageFailure })
+	set("message.cancelled", len(pr.MessageCancelled) > 0, func() { cfg.MessageCancelled = pr.MessageCancelled })
+	set("message.success.file", len(pr.MessageFileSuccess) > 0, func() { cfg.MessageFileSuccess = pr.MessageFileSuccess })
+	set("message.failure.file", len(pr.MessageFileFailure) > 0, func() { cfg.MessageFileFailure = pr.MessageFileFailure })
+	set("message.cancelled.file", len(pr.MessageFileCancelled) > 0, func() { cfg.MessageFileCancelled = pr.MessageFileCancelled })
+	set("template.vars.file", len(pr.TemplateVarsFile) > 0, func() { cfg.TemplateVarsFile = pr.TemplateVarsFile })
+	set("photo", len(pr.Photo) > 0, func() { cfg.Photo = pr.Photo })
+	set("document", len(pr.Document) > 0, func() { cfg.Document = pr.Document })
+	set("sticker", len(pr.Sticker) > 0, func() { cfg.Sticker = pr.Sticker })
+	set("audio", len(pr.Audio) > 0, func() { cfg.Audio = pr.Audio })
+	set("voice", len(pr.Voice) > 0, func() { cfg.Voice = pr.Voice })
+	set("location", len(pr.Location) > 0, func() { cfg.Location = pr.Location })
+	set("video", len(pr.Video) > 0, func() { cfg.Video = pr.Video })
+	set("venue", len(pr.Venue) > 0, func() { cfg.Venue = pr.Venue })
+	set("format", len(pr.Format) > 0, func() { cfg.Format = pr.Format })
+	set("lang", len(pr.Lang) > 0, func() { cfg.Lang = pr.Lang })
+	set("lang.file", len(pr.LangFile) > 0, func() { cfg.LangFile = pr.LangFile })
+	set("time.zone", len(pr.TimeZone) > 0, func() { cfg.TimeZone = pr.TimeZone })
+	set("time.layout", len(pr.TimeLayout) > 0, func() { cfg.TimeLayout = pr.TimeLayout })
+	set("result.file", len(pr.ResultFile) > 0, func() { cfg.ResultFile = pr.ResultFile })
+	set("api.url", len(pr.APIURL) > 0, func() { cfg.APIURL = pr.APIURL })
+	set("proxy", len(pr.Proxy) > 0, func() { cfg.Proxy = pr.Proxy })
+	set("ca.cert", len(pr.CACert) > 0, func() { cfg.CACert = pr.CACert })
+	set("client.cert", len(pr.ClientCert) > 0, func() { cfg.ClientCert = pr.ClientCert })
+	set("client.key", len(pr.ClientKey) > 0, func() { cfg.ClientKey = pr.ClientKey })
+	set("tls.min.version", len(pr.TLSMinVersion) > 0, func() { cfg.TLSMinVersion = pr.TLSMinVersion })
+	set("log.format", len(pr.LogFormat) > 0, func() { cfg.LogFormat = pr.LogFormat })
+	set("log.level", len(pr.LogLevel) > 0, func() { cfg.LogLevel = pr.LogLevel })
+	set("timeout", pr.Timeout > 0, func() { cfg.Timeout = pr.Timeout })
+	set("total.timeout", pr.TotalTimeout > 0, func() { cfg.TotalTimeout = pr.TotalTimeout })
+	set("socks5", len(pr.Socks5) > 0, func() { cfg.Socks5 = pr.Socks5 })
+	set("match.email", pr.MatchEmail != nil, func() { cfg.MatchEmail = *pr.MatchEmail })
+	set("disable.webpage.preview", pr.DisableWebPagePreview != nil, func() {
+		cfg.DisableWebPagePreview = *pr.DisableWebPagePreview
+	})
+	set("disable.notification", pr.DisableNotification != nil, func() {
+		cfg.DisableNotification = *pr.DisableNotification
+	})
+
+	set("template.vars", len(pr.TemplateVars) > 0, func() {
+		// a map of strings always marshals
+		vars, _ := json.Marshal(pr.TemplateVars)
+		cfg.TemplateVars = string(vars)
+	})
+}
+
+// explicitFlags returns whether a flag of the app was given a value, so it
+// wins over the profiles. c.IsSet also reports a flag whose environment
+// variable is empty, and GitHub Actions exports one for every input.
+func explicitFlags(c *cli.Context) func(name string) bool {
+	// an empty variable sets a list to a single empty string, the flags that
+	// are not strings are empty when the first of their variables that
+	// exists is
+	fromEnv := func(envVars string) func() bool {
+		return func() bool {
+			for envVar := range strings.SplitSeq(envVars, ",") {
+				if val, ok := os.LookupEnv(strings.TrimSpace(envVar)); ok {
+					return len(val) > 0
+				}
+			}
+			return true
+		}
+	}
+
+	given := map[string]func() bool{}
+	for _, f := range c.App.Flags {
+		name := f.GetName()
+		switch f := f.(type) {
+		case cli.StringFlag:
+			given[name] = func() bool { return len(c.String(name)) > 0 }
+		case cli.StringSliceFlag:
+			given[name] = func() bool {
+				return slices.ContainsFunc(c.StringSlice(name), func(s string) bool { return len(s) > 0 })
+			}
+		case cli.BoolFlag:
+			given[name] = fromEnv(f.EnvVar)
+		case cli.IntFlag:
+			given[name] = fromEnv(f.EnvVar)
+		case cli.DurationFlag:
+			given[name] = fromEnv(f.EnvVar)
+		}
+	}
+
+	return func(name string) bool {
+		if !c.IsSet(name) {
+			return false
+		}
+		if given, ok := given[name]; ok {
+			return given()
+		}
+		return true
+	}
+}
+
+// forEachProfile calls fn with a copy of the plugin for every profile
+// selected from the config file, or with the plugin itself when there is
+// no config file, and joins the errors of all profiles.
+func forEachProfile(c *cli.Context, plugin Plugin, fn func(p *Plugin) error) error {
+	file := c.String("config.file")
+	if len(file) == 0 {
+		return fn(&plugin)
+	}
+
+	profiles, err := loadProfiles(file)
+	if err != nil {
+		return err
+	}
+
+	profiles, err = selectProfiles(profiles, c.StringSlice("profile"))
+	if err != nil {
+		return err
+	}
+
+	isSet := explicitFlags(c)
+
+	// profiles usually share the result file and the step outputs, so the
+	// results are collected per file and written once, and the plans of a
+	// dry run are printed as one list
+	var (
+		errs    []error
+		files   []string
+		outputs []string
+		plan    []Planned
+	)
+	byFile := map[string][]Result{}
+	byOutput := map[string][]Result{}
+	for _, profile := range profiles {
+		p := plugin
+		p.profile = profile.Name
+		profile.apply(&p.Config, isSet)
+		if err := fn(&p); err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", profile.Name, err))
+		} else if p.plan != nil {
+			plan = append(plan, p.plan...)
+		}
+
+		// dry runs and checks leave the results nil
+		if p.results == nil {
+			continue
+		}
+		if file := p.Config.ResultFile; len(file) > 0 {
+			if _, ok := byFile[file]; !ok {
+				files = append(files, file)
+			}
+			byFile[file] = append(byFile[file], p.results...)
+		}
+		if output := p.Config.GitHubOutput; len(output) > 0 {
+			if _, ok := byOutput[output]; !ok {
+				outputs = append(outputs, output)
+			}
+			byOutput[output] = append(byOutput[output], p.results...)
+		}
+	}
+
+	if plan != nil {
+		if err := printPlan(os.Stdout, plan); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, file := range files {
+		if err := writeResults(byFile[file], file, ""); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, output := range outputs {
+		if err := writeResults(byOutput[output], "", output); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	profiles, err := loadProfiles("tests/profiles.yml")
+	require.NoError(t, err)
+	require.Len(t, profiles, 2)
+
+	yes, no := true, false
+	assert.Equal(t, Profile{
+		Name:                "terse",
+		Token:               "terse-token",
+		To:                  stringList{"-1001234567890"},
+		Message:             "{{repo.name}} {{build.status}}",
+		DisableNotification: &yes,
+	}, profiles[0])
+	assert.Equal(t, Profile{
+		Name:                  "verbose",
+		Token:                 "verbose-token",
+		To:                    stringList{"-1009876543210", "111:appleboy.tw@gmail.com"},
+		MessageThreadID:       12,
+		Format:                "html",
+		MessageFile:           "tests/message_html.txt",
+		TemplateVars:          map[string]string{"env": "production"},
+		Photo:                 stringList{"tests/github.png"},
+		DisableWebPagePreview: &no,
+	}, profiles[1])
+}
+
+func TestLoadProfilesErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+		return file
+	}
+
+	_, err := loadProfiles(filepath.Join(dir, "missing.yml"))
+	assert.ErrorContains(t, err, "unable to read config file")
+
+	_, err = loadProfiles(write("typo.yml", "profiles:\n  a:\n    tokne: x\n"))
+	assert.ErrorContains(t, err, "field tokne not found")
+
+	_, err = loadProfiles(write("empty.yml", "profiles: {}\n"))
+	assert.ErrorContains(t, err, "has no profiles")
+}
+
+func TestSelectProfiles(t *testing.T) {
+	profiles := []Profile{{Name: "terse"}, {Name: "verbose"}}
+
+	selected, err := selectProfiles(profiles, nil)
+	require.NoError(t, err)
+	assert.Equal(t, profiles, selected)
+
+	selected, err = selectProfiles(profiles, []string{"verbose"})
+	require.NoError(t, err)
+	assert.Equal(t, []Profile{{Name: "verbose"}}, selected)
+
+	_, err = selectProfiles(profiles, []string{"loud"})
+	assert.EqualError(t, err, "unknown profile 'loud'")
+}
+
+func TestProfileApply(t *testing.T) {
+	cfg := Config{Token: "flag-token", Format: formatMarkdown, DisableWebPagePreview: true}
+	Profile{
+		Token:        "profile-token",
+		To:           stringList{"1234"},
+		Format:       "html",
+		TemplateVars: map[string]string{"env": "production"},
+	}.apply(&cfg, func(name string) bool { return name == "token" })
+
+	assert.Equal(t, Config{
+		Token:                 "flag-token",
+		To:                    []string{"1234"},
+		Format:                "html",
+		TemplateVars:          `{"env":"production"}`,
+		DisableWebPagePreview: true,
+	}, cfg)
+}
+
+func TestForEachProfile(t *testing.T) {
+	var configs []Config
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "config.file"},
+		cli.StringSliceFlag{Name: "profile"},
+		cli.StringFlag{Name: "format", Value: formatMarkdown},
+	}
+	app.Action = func(c *cli.Context) error {
+		plugin := Plugin{Config: Config{Format: c.String("format")}}
+		return forEachProfile(c, plugin, func(p *Plugin) error {
+			configs = append(configs, p.Config)
+			if p.Config.Token == "verbose-token" {
+				return assert.AnError
+			}
+			return nil
+		})
+	}
+
+	err := app.Run([]string{"telegram", "--config.file", "tests/profiles.yml"})
+	assert.EqualError(t, err, "profile verbose: "+assert.AnError.Error())
+	require.Len(t, configs, 2)
+	assert.Equal(t, "terse-token", configs[0].Token)
+	assert.Equal(t, formatMarkdown, configs[0].Format)
+	assert.Equal(t, "html", configs[1].Format)
+
+	// explicit flags win over the profile
+	configs = nil
+	err = app.Run([]string{"telegram", "--config.file", "tests/profiles.yml", "--profile", "verbose", "--format", formatMarkdown})
+	assert.Error(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, formatMarkdown, configs[0].Format)
+	assert.Equal(t, 12, configs[0].MessageThreadID)
+
+	// without a config file the plugin runs once
+	configs = nil
+	require.NoError(t, app.Run([]string{"telegram"}))
+	assert.Equal(t, []Config{{Format: formatMarkdown}}, configs)
+}
+
+func TestForEachProfileEmptyEnv(t *testing.T) {
+	// GitHub Actions exports an empty variable for every input left out
+	t.Setenv("INPUT_TO", "")
+	t.Setenv("INPUT_MESSAGE", "")
+	t.Setenv("INPUT_DISABLE_NOTIFICATION", "")
+	t.Setenv("INPUT_FORMAT", "html")
+
+	var configs []Config
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "config.file"},
+		cli.StringSliceFlag{Name: "profile"},
+		cli.StringSliceFlag{Name: "to", EnvVar: "INPUT_TO"},
+		cli.StringFlag{Name: "message", EnvVar: "INPUT_MESSAGE"},
+		cli.StringFlag{Name: "format", EnvVar: "INPUT_FORMAT"},
+		cli.BoolFlag{Name: "disable.notification", EnvVar: "INPUT_DISABLE_NOTIFICATION"},
+	}
+	app.Action = func(c *cli.Context) error {
+		return forEachProfile(c, Plugin{Config: Config{Format: c.String("format")}}, func(p *Plugin) error {
+			configs = append(configs, p.Config)
+			return nil
+		})
+	}
+
+	require.NoError(t, app.Run([]string{"telegram", "--config.file", "tests/profiles.yml", "--profile", "terse"}))
+	assert.Equal(t, []Config{{
+		Token:               "terse-token",
+		To:                  []string{"-1001234567890"},
+		Message:             "{{repo.name}} {{build.status}}",
+		Format:              "html",
+		DisableNotification: true,
+	}}, configs)
+}
+
+func TestForEachProfilePlan(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "config.file"},
+		cli.StringSliceFlag{Name: "profile"},
+	}
+	app.Action = func(c *cli.Context) error {
+		plugin := Plugin{Config: Config{Message: "hello", DryRun: true}}
+		return forEachProfile(c, plugin, func(p *Plugin) error {
+			p.Config.Photo = nil
+			p.Config.MessageFile = ""
+			return p.Exec(t.Context())
+		})
+	}
+
+	require.NoError(t, app.Run([]string{"telegram", "--config.file", "tests/profiles.yml"}))
+	require.NoError(t, w.Close())
+	output, err := io.ReadAll(r)
+	require.NoError(t, err)
+
+	// one list for all profiles
+	assert.JSONEq(t, `[
+		{"profile": "terse", "chat_id": -1001234567890, "kind": "message", "disable_notification": true},
+		{"profile": "verbose", "chat_id": -1009876543210, "message_thread_id": 12, "kind": "message", "text": "hello", "parse_mode": "html"}
+	]`, string(output))
+}
+
+func TestForEachProfileResults(t *testing.T) {
+	server := newBotServer(t, map[string]string{
+		"getMe":                      botResult(testBot),
+		"sendMessage:-1001234567890": botResult(`{"message_id": 1, "chat": {"id": -1001234567890}}`),
+		"sendMessage:-1009876543210": botResult(`{"message_id": 2, "chat": {"id": -1009876543210}}`),
+	})
+
+	dir := t.TempDir()
+	resultFile := filepath.Join(dir, "result.json")
+	githubOutput := filepath.Join(dir, "github_output")
+	configFile := filepath.Join(dir, "profiles.yml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`profiles:
+  terse:
+    to: "-1001234567890"
+    message: terse
+  verbose:
+    to: "-1009876543210"
+    message: verbose
+`), 0o600))
+
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "config.file"},
+		cli.StringSliceFlag{Name: "profile"},
+	}
+	app.Action = func(c *cli.Context) error {
+		plugin := Plugin{Config: Config{
+			Token:        "123456:ABC",
+			APIURL:       server.URL,
+			ResultFile:   resultFile,
+			GitHubOutput: githubOutput,
+		}}
+		return forEachProfile(c, plugin, func(p *Plugin) error {
+			return p.Exec(t.Context())
+		})
+	}
+
+	require.NoError(t, app.Run([]string{"telegram", "--config.file", configFile}))
+
+	content, err := os.ReadFile(resultFile)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"chat_id": -1001234567890, "message_id": 1, "kind": "message", "link": "https://t.me/c/1234567890/1", "profile": "terse"},
+		{"chat_id": -1009876543210, "message_id": 2, "kind": "message", "link": "https://t.me/c/9876543210/2", "profile": "verbose"}
+	]`, string(content))
+
+	// the step outputs are written once, for the first message
+	output, err := os.ReadFile(githubOutput)
+	require.NoError(t, err)
+	assert.Equal(t, "chat_id=-1001234567890\nmessage_id=1\nlink=https://t.me/c/1234567890/1\nresults="+
+		strings.TrimSpace(string(content))+"\n", string(output))
+}
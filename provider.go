@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	providerAuto       = "auto"
+	providerDrone      = "drone"
+	providerGitHub     = "github"
+	providerGitLab     = "gitlab"
+	providerGitea      = "gitea"
+	providerWoodpecker = "woodpecker"
+	providerJenkins    = "jenkins"
+	providerBitbucket  = "bitbucket"
+	providerCircleCI   = "circleci"
+)
+
+// providerMarkers lists the environment markers of each CI system in
+// detection order. Gitea and Forgejo also set GITHUB_ACTIONS, and older
+// Woodpecker releases set DRONE, so they are checked first.
+var providerMarkers = []struct {
+	provider string
+	detect   func(getenv func(string) string) bool
+}{
+	{providerGitea, func(getenv func(string) string) bool {
+		return getenv("GITEA_ACTIONS") == "true" || getenv("FORGEJO_ACTIONS") == "true"
+	}},
+	{providerGitHub, func(getenv func(string) string) bool { return getenv("GITHUB_ACTIONS") == "true" }},
+	{providerGitLab, func(getenv func(string) string) bool { return getenv("GITLAB_CI") == "true" }},
+	{providerWoodpecker, func(getenv func(string) string) bool { return getenv("CI") == "woodpecker" }},
+	{providerDrone, func(getenv func(string) string) bool { return getenv("DRONE") == "true" }},
+	{providerJenkins, func(getenv func(string) string) bool { return len(getenv("JENKINS_URL")) > 0 }},
+	{providerBitbucket, func(getenv func(string) string) bool { return len(getenv("BITBUCKET_BUILD_NUMBER")) > 0 }},
+	{providerCircleCI, func(getenv func(string) string) bool { return getenv("CIRCLECI") == "true" }},
+}
+
+// providerAliases maps alternative names to a supported provider.
+var providerAliases = map[string]string{
+	"forgejo":             providerGitea,
+	"github-actions":      providerGitHub,
+	"gitlab-ci":           providerGitLab,
+	"bitbucket-pipelines": providerBitbucket,
+}
+
+// detectProvider returns the CI system found in the environment, Drone when
+// none is recognized.
+func detectProvider(getenv func(string) string) string {
+	for _, m := range providerMarkers {
+		if m.detect(getenv) {
+			return m.provider
+		}
+	}
+
+	return providerDrone
+}
+
+// resolveProvider returns the provider named by the setting, detecting it
+// from the environment when the setting is empty or auto.
+func resolveProvider(name string, getenv func(string) string) (string, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := providerAliases[key]; ok {
+		key = alias
+	}
+
+	if len(key) == 0 || key == providerAuto {
+		return detectProvider(getenv), nil
+	}
+
+	for _, m := range providerMarkers {
+		if m.provider == key {
+			return key, nil
+		}
+	}
+
+	return "", fmt.Errorf("unsupported provider '%s'", name)
+}
+
+// loadProviderEnv fills the plugin from the environment of the configured
+// provider. Drone and GitHub Actions settings come from the cli flags. A
+// failure of git is kept for Exec to warn about.
+func (p *Plugin) loadProviderEnv(getenv func(string) string) {
+	switch p.Config.Provider {
+	case providerGitLab:
+		p.loadGitLabEnv(getenv)
+	case providerWoodpecker:
+		p.loadWoodpeckerEnv(getenv)
+	case providerGitea:
+		p.loadGiteaEnv(getenv)
+	case providerJenkins:
+		p.loadJenkinsEnv(getenv)
+		p.gitErr = p.loadGitCommit()
+	case providerBitbucket:
+		p.loadBitbucketEnv(getenv)
+		p.loadGitEnv(getenv)
+		p.gitErr = p.loadGitCommit()
+	case providerCircleCI:
+		p.loadCircleCIEnv(getenv)
+		p.loadGitEnv(getenv)
+		p.gitErr = p.loadGitCommit()
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/appleboy/drone-template-lib/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectProvider(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"GITHUB_ACTIONS": "true"}, providerGitHub},
+		{map[string]string{"GITHUB_ACTIONS": "true", "GITEA_ACTIONS": "true"}, providerGitea},
+		{map[string]string{"GITHUB_ACTIONS": "true", "FORGEJO_ACTIONS": "true"}, providerGitea},
+		{map[string]string{"GITLAB_CI": "true", "CI": "true"}, providerGitLab},
+		{map[string]string{"CI": "woodpecker", "DRONE": "true"}, providerWoodpecker},
+		{map[string]string{"DRONE": "true"}, providerDrone},
+		{map[string]string{"JENKINS_URL": "https://jenkins.example.com/"}, providerJenkins},
+		{map[string]string{"BITBUCKET_BUILD_NUMBER": "42"}, providerBitbucket},
+		{map[string]string{"CIRCLECI": "true"}, providerCircleCI},
+		{nil, providerDrone},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, detectProvider(mapEnv(tt.env)), tt.env)
+	}
+}
+
+func TestResolveProvider(t *testing.T) {
+	env := mapEnv(map[string]string{"GITLAB_CI": "true"})
+
+	provider, err := resolveProvider("", env)
+	assert.NoError(t, err)
+	assert.Equal(t, providerGitLab, provider)
+
+	provider, err = resolveProvider("auto", env)
+	assert.NoError(t, err)
+	assert.Equal(t, providerGitLab, provider)
+
+	// an explicit provider wins over the environment
+	provider, err = resolveProvider("Jenkins", env)
+	assert.NoError(t, err)
+	assert.Equal(t, providerJenkins, provider)
+
+	provider, err = resolveProvider("forgejo", env)
+	assert.NoError(t, err)
+	assert.Equal(t, providerGitea, provider)
+
+	_, err = resolveProvider("travis", env)
+	assert.EqualError(t, err, "unsupported provider 'travis'")
+}
+
+func TestLoadProviderEnv(t *testing.T) {
+	plugin := Plugin{Config: Config{Provider: providerGitLab}}
+	plugin.loadProviderEnv(mapEnv(map[string]string{"CI_PROJECT_PATH": "appleboy/go-hello"}))
+	assert.Equal(t, "appleboy/go-hello", plugin.Repo.FullName)
+
+	// drone settings come from the cli flags only
+	plugin = Plugin{Config: Config{Provider: providerDrone}}
+	plugin.loadProviderEnv(mapEnv(map[string]string{"CI_PROJECT_PATH": "appleboy/go-hello"}))
+	assert.Empty(t, plugin.Repo.FullName)
+}
+
+func TestProviderTemplate(t *testing.T) {
+	plugin := Plugin{CI: CI{Provider: providerWoodpecker}}
+
+	rendered, err := template.RenderTrim("sent from {{ ci.provider }}", plugin)
+	assert.NoError(t, err)
+	assert.Equal(t, "sent from woodpecker", rendered)
+}
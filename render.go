@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiStrike    = "\x1b[9m"
+	ansiCode      = "\x1b[36m"
+	ansiFaint     = "\x1b[2m"
+)
+
+// fixture holds the template data of a render preview.
+type fixture struct {
+	Repo   Repo              `json:"repo"`
+	Commit Commit            `json:"commit"`
+	Build  Build             `json:"build"`
+	Tpl    map[string]string `json:"tpl"`
+}
+
+var renderCommand = cli.Command{
+	Name:      "render",
+	Usage:     "render a message template locally and print the result",
+	ArgsUsage: "[template file]",
+	Action:    renderAction,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "fixture",
+			Usage: "json file with the repo, commit, build and tpl template data",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: formatMarkdown,
+			Usage: "telegram message format (Markdown or HTML)",
+		},
+		cli.StringFlag{
+			Name:  "lang",
+			Value: defaultLang,
+			Usage: "language of the default message (en, zh-CN, zh-TW or ru)",
+		},
+		cli.StringFlag{
+			Name:  "time.zone",
+			Value: "UTC",
+			Usage: "time zone used to format build times in templates (e.g. Asia/Taipei)",
+		},
+		cli.StringFlag{
+			Name:  "provider",
+			Value: providerDrone,
+			Usage: "CI provider whose layout the default message uses",
+		},
+		cli.BoolFlag{
+			Name:  "ansi",
+			Usage: "approximate the telegram styling with terminal colors",
+		},
+	},
+}
+
+// renderAction prints the messages rendered from the template file, or
+// the default message when no file is given, and the fixture data.
+func renderAction(c *cli.Context) error {
+	plugin := Plugin{
+		Config: Config{
+			MessageFile: c.Args().First(),
+			Format:      c.String("format"),
+			Lang:        c.String("lang"),
+			TimeZone:    c.String("time.zone"),
+			Provider:    c.String("provider"),
+		},
+	}
+
+	if path := c.String("fixture"); len(path) > 0 {
+		data, err := loadFixture(path)
+		if err != nil {
+			return err
+		}
+		plugin.Repo, plugin.Commit, plugin.Build, plugin.Tpl = data.Repo, data.Commit, data.Build, data.Tpl
+	}
+
+	messages, err := plugin.render()
+	if err != nil {
+		return err
+	}
+
+	for i, message := range messages {
+		if i > 0 {
+			fmt.Fprintln(c.App.Writer, "---")
+		}
+		if c.Bool("ansi") {
+			message = styleANSI(message, plugin.Config.Format)
+		}
+		fmt.Fprintln(c.App.Writer, message)
+	}
+
+	return nil
+}
+
+// loadFixture reads the template data of a render preview.
+func loadFixture(path string) (fixture, error) {
+	var data fixture
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return data, fmt.Errorf("unable to read fixture '%s': %w", path, err)
+	}
+
+	if err := json.Unmarshal(content, &data); err != nil {
+		return data, fmt.Errorf("unable to unmarshal fixture '%s': %w", path, err)
+	}
+
+	return data, nil
+}
+
+var (
+	markdownCode = regexp.MustCompile("(?s)```(.*?)```|`([^`\n]+)`")
+	markdownBold = regexp.MustCompile(`\*([^*\n]+)\*`)
+	markdownLink = regexp.MustCompile(`\[([^\]\n]+)\]\(([^)\s]+)\)`)
+
+	htmlCode = regexp.MustCompile(`(?is)<(pre|code)[^>]*>(.*?)</(?:pre|code)>`)
+	htmlTag  = regexp.MustCompile(`(?is)<(/?)(b|strong|i|em|u|ins|s|strike|del|tg-spoiler|blockquote)(?:\s[^>]*)?>`)
+	htmlLink = regexp.MustCompile(`(?is)<a\s+href=["']([^"']*)["'][^>]*>(.*?)</a>`)
+)
+
+var htmlStyles = map[string]string{
+	"b":          ansiBold,
+	"strong":     ansiBold,
+	"i":          ansiItalic,
+	"em":         ansiItalic,
+	"u":          ansiUnderline,
+	"ins":        ansiUnderline,
+	"s":          ansiStrike,
+	"strike":     ansiStrike,
+	"del":        ansiStrike,
+	"tg-spoiler": ansiFaint,
+	"blockquote": ansiFaint,
+}
+
+// styleANSI approximates how telegram shows a message in the given
+// format with terminal escape codes. Nested styles are not tracked, a
+// closing tag resets all of them.
+func styleANSI(message, format string) string {
+	if strings.EqualFold(format, formatHTML) {
+		return styleHTML(message)
+	}
+
+	return styleMarkdown(message)
+}
+
+func styleMarkdown(message string) string {
+	var b strings.Builder
+
+	// code is shown as is, so only style the text between code spans
+	last := 0
+	for _, m := range markdownCode.FindAllStringSubmatchIndex(message, -1) {
+		b.WriteString(styleMarkdownText(message[last:m[0]]))
+		start, end := m[2], m[3]
+		if start < 0 {
+			start, end = m[4], m[5]
+		}
+		b.WriteString(ansiCode + strings.TrimSpace(message[start:end]) + ansiReset)
+		last = m[1]
+	}
+	b.WriteString(styleMarkdownText(message[last:]))
+
+	return b.String()
+}
+
+func styleMarkdownText(text string) string {
+	text = markdownLink.ReplaceAllString(text, ansiUnderline+"$1"+ansiReset+" ($2)")
+	text = markdownBold.ReplaceAllString(text, ansiBold+"$1"+ansiReset)
+
+	return strings.NewReplacer(`\_`, "_", `\*`, "*", "\\`", "`", `\[`, "[").Replace(text)
+}
+
+func styleHTML(message string) string {
+	var b strings.Builder
+
+	last := 0
+	for _, m := range htmlCode.FindAllStringSubmatchIndex(message, -1) {
+		b.WriteString(styleHTMLText(message[last:m[0]]))
+		b.WriteString(ansiCode + html.UnescapeString(message[m[4]:m[5]]) + ansiReset)
+		last = m[1]
+	}
+	b.WriteString(styleHTMLText(message[last:]))
+
+	return b.String()
+}
+
+func styleHTMLText(text string) string {
+	text = htmlLink.ReplaceAllString(text, ansiUnderline+"$2"+ansiReset+" ($1)")
+	text = htmlTag.ReplaceAllStringFunc(text, func(tag string) string {
+		m := htmlTag.FindStringSubmatch(tag)
+		if m[1] == "/" {
+			return ansiReset
+		}
+
+		return htmlStyles[strings.ToLower(m[2])]
+	})
+
+	return html.UnescapeString(text)
+}
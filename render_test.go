@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func runRender(t *testing.T, args ...string) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	app := cli.NewApp()
+	app.Writer = &buf
+	app.Commands = []cli.Command{renderCommand}
+
+	require.NoError(t, app.Run(append([]string{"telegram", "render"}, args...)))
+
+	return buf.String()
+}
+
+func TestRenderCommand(t *testing.T) {
+	assert.Equal(
+		t,
+		"Sample message template loaded from file.\n\n*Environ:* testing\n*Version:* v1.2.3\n\nCommit msg:  update travis by drone plugin\n\nduration: 4m12s\n",
+		runRender(t, "--fixture", "tests/fixture.json", "tests/message_template.txt"),
+	)
+
+	assert.Equal(
+		t,
+		"✅ Build #101 of `appleboy/go-hello` success (took 4m12s).\n\n📝 Commit by appleboy on `master`:\n``` update travis by drone plugin ```\n\n🌐 https://cloud.drone.io/appleboy/go-hello/101\n",
+		runRender(t, "--fixture", "tests/fixture.json"),
+	)
+}
+
+func TestRenderCommandANSI(t *testing.T) {
+	assert.Equal(
+		t,
+		"Test HTML Format from file\n"+
+			ansiUnderline+"Google .com 1"+ansiReset+" (https://google.com)\n"+
+			ansiUnderline+"Google .com 2"+ansiReset+" (https://google.com)\n"+
+			ansiUnderline+"Google .com 3"+ansiReset+" (https://google.com)\n"+
+			ansiUnderline+"Google .com 4"+ansiReset+" (https://google.com)\n",
+		runRender(t, "--format", "html", "--ansi", "tests/message_html.txt"),
+	)
+}
+
+func TestRenderCommandErrors(t *testing.T) {
+	app := cli.NewApp()
+	app.Writer = &bytes.Buffer{}
+	app.Commands = []cli.Command{renderCommand}
+
+	assert.Error(t, app.Run([]string{"telegram", "render", "--fixture", "tests/missing.json"}))
+	assert.Error(t, app.Run([]string{"telegram", "render", "--fixture", "tests/message.txt"}))
+	assert.Error(t, app.Run([]string{"telegram", "render", "tests/missing.tpl"}))
+}
+
+func TestStyleANSI(t *testing.T) {
+	assert.Equal(
+		t,
+		ansiBold+"Build"+ansiReset+" of "+ansiCode+"go-hello"+ansiReset+" by my_name "+
+			ansiUnderline+"log"+ansiReset+" (https://example.com)\n"+ansiCode+"*not bold*"+ansiReset,
+		styleANSI("*Build* of `go-hello` by my\\_name [log](https://example.com)\n``` *not bold* ```", formatMarkdown),
+	)
+
+	assert.Equal(
+		t,
+		ansiBold+"Build"+ansiReset+" "+ansiItalic+"#1"+ansiReset+" "+ansiCode+"a < b"+ansiReset+" & "+ansiStrike+"old"+ansiReset,
+		styleANSI("<b>Build</b> <i>#1</i> <pre>a &lt; b</pre> &amp; <s>old</s>", formatHTML),
+	)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+)
+
+// Result describes a message delivered to a chat, so later steps can
+// reply to, edit or delete it.
+type Result struct {
+	ChatID    int64  `json:"chat_id"`
+	MessageID int    `json:"message_id"`
+	ThreadID  int    `json:"message_thread_id,omitempty"`
+	Kind      string `json:"kind"`
+	Link      string `json:"link,omitempty"`
+	Profile   string `json:"profile,omitempty"`
+}
+
+// newResult returns the result of a message sent as kind.
+func newResult(kind string, message tgbotapi.Message) Result {
+	result := Result{
+		ChatID:    message.Chat.ID,
+		MessageID: message.MessageID,
+		Kind:      kind,
+	}
+	if message.IsTopicMessage {
+		result.ThreadID = message.MessageThreadID
+	}
+	result.Link = messageLink(message.Chat, result.MessageID, result.ThreadID)
+
+	return result
+}
+
+// messageKind returns the kind of message a config sends.
+func messageKind(msg tgbotapi.Chattable) string {
+	switch msg.(type) {
+	case tgbotapi.PhotoConfig:
+		return "photo"
+	case tgbotapi.DocumentConfig:
+		return "document"
+	case tgbotapi.StickerConfig:
+		return "sticker"
+	case tgbotapi.AudioConfig:
+		return "audio"
+	case tgbotapi.VoiceConfig:
+		return "voice"
+	case tgbotapi.VideoConfig:
+		return "video"
+	case tgbotapi.LocationConfig:
+		return "location"
+	case tgbotapi.VenueConfig:
+		return "venue"
+	default:
+		return "message"
+	}
+}
+
+// messageLink returns the t.me permalink of a message. Only public chats
+// and supergroups or channels have one, private chats and basic groups
+// return an empty string.
+func messageLink(chat tgbotapi.Chat, messageID, threadID int) string {
+	var base string
+	switch {
+	case len(chat.UserName) > 0:
+		base = "https://t.me/" + chat.UserName
+	case strings.HasPrefix(strconv.FormatInt(chat.ID, 10), "-100"):
+		// supergroup and channel ids are the internal id prefixed with -100
+		base = "https://t.me/c/" + strings.TrimPrefix(strconv.FormatInt(chat.ID, 10), "-100")
+	default:
+		return ""
+	}
+
+	if threadID > 0 {
+		base += "/" + strconv.Itoa(threadID)
+	}
+
+	return base + "/" + strconv.Itoa(messageID)
+}
+
+// writeResults stores the delivered messages in the result file and in the
+// GitHub Actions step outputs, whichever is configured.
+func (p *Plugin) writeResults() error {
+	return writeResults(p.results, p.Config.ResultFile, p.Config.GitHubOutput)
+}
+
+// writeResults stores results in a result file and in GitHub Actions step
+// outputs, skipping the empty ones.
+func writeResults(results []Result, resultFile, githubOutput string) error {
+	if results == nil {
+		results = []Result{}
+	}
+
+	content, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+
+	if len(resultFile) > 0 {
+		if err := os.MkdirAll(filepath.Dir(resultFile), 0o755); err != nil {
+			return fmt.Errorf("unable to create result file '%s': %w", resultFile, err)
+		}
+		if err := os.WriteFile(resultFile, append(content, '\n'), 0o644); err != nil {
+			return fmt.Errorf("unable to write result file '%s': %w", resultFile, err)
+		}
+	}
+
+	if len(githubOutput) == 0 {
+		return nil
+	}
+
+	// the first message is the one most steps reply to
+	var first Result
+	if len(results) > 0 {
+		first = results[0]
+	}
+
+	output := fmt.Sprintf(
+		"chat_id=%d\nmessage_id=%d\nlink=%s\nresults=%s\n",
+		first.ChatID,
+		first.MessageID,
+		first.Link,
+		content,
+	)
+
+	f, err := os.OpenFile(githubOutput, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("unable to open GitHub output '%s': %w", githubOutput, err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(output); err != nil {
+		return fmt.Errorf("unable to write GitHub output '%s': %w", githubOutput, err)
+	}
+
+	return nil
+}
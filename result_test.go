@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tgbotapi "github.com/OvyFlash/telegram-bot-api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageLink(t *testing.T) {
+	tests := []struct {
+		chat     tgbotapi.Chat
+		threadID int
+		wantLink string
+	}{
+		{tgbotapi.Chat{ID: -1001234567890, Type: "supergroup"}, 0, "https://t.me/c/1234567890/42"},
+		{tgbotapi.Chat{ID: -1001234567890, Type: "supergroup"}, 7, "https://t.me/c/1234567890/7/42"},
+		{tgbotapi.Chat{ID: -1001234567890, Type: "channel", UserName: "drone_ci"}, 0, "https://t.me/drone_ci/42"},
+		{tgbotapi.Chat{ID: -123456789, Type: "group"}, 0, ""},
+		{tgbotapi.Chat{ID: 123456789, Type: "private"}, 0, ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.wantLink, messageLink(tt.chat, 42, tt.threadID))
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	message := tgbotapi.Message{
+		MessageID:       42,
+		MessageThreadID: 7,
+		IsTopicMessage:  true,
+		Chat:            tgbotapi.Chat{ID: -1001234567890, Type: "supergroup"},
+	}
+
+	msg := tgbotapi.NewPhoto(-1001234567890, tgbotapi.FilePath("tests/github.png"))
+	assert.Equal(
+		t,
+		Result{
+			ChatID:    -1001234567890,
+			MessageID: 42,
+			ThreadID:  7,
+			Kind:      "photo",
+			Link:      "https://t.me/c/1234567890/7/42",
+		},
+		newResult(messageKind(msg), message),
+	)
+
+	// replies in a thread outside of forum topics do not link to a topic
+	message.IsTopicMessage = false
+	assert.Equal(t, 0, newResult("message", message).ThreadID)
+	assert.Equal(t, "message", messageKind(tgbotapi.NewMessage(1, "hi")))
+}
+
+func TestWriteResults(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "github_output")
+	require.NoError(t, os.WriteFile(output, []byte("foo=bar\n"), 0o644))
+
+	plugin := Plugin{
+		Config: Config{
+			ResultFile:   filepath.Join(dir, ".telegram", "result.json"),
+			GitHubOutput: output,
+		},
+		results: []Result{
+			{ChatID: -1001234567890, MessageID: 42, Kind: "message", Link: "https://t.me/c/1234567890/42"},
+			{ChatID: 123456789, MessageID: 7, Kind: "photo"},
+		},
+	}
+
+	require.NoError(t, plugin.writeResults())
+
+	content, err := os.ReadFile(plugin.Config.ResultFile)
+	require.NoError(t, err)
+	assert.JSONEq(
+		t,
+		`[
+			{"chat_id": -1001234567890, "message_id": 42, "kind": "message", "link": "https://t.me/c/1234567890/42"},
+			{"chat_id": 123456789, "message_id": 7, "kind": "photo"}
+		]`,
+		string(content),
+	)
+
+	content, err = os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"foo=bar\nchat_id=-1001234567890\nmessage_id=42\nlink=https://t.me/c/1234567890/42\nresults="+
+			`[{"chat_id":-1001234567890,"message_id":42,"kind":"message","link":"https://t.me/c/1234567890/42"},{"chat_id":123456789,"message_id":7,"kind":"photo"}]`+"\n",
+		string(content),
+	)
+}
+
+func TestWriteResultsEmpty(t *testing.T) {
+	plugin := Plugin{Config: Config{ResultFile: filepath.Join(t.TempDir(), "result.json")}}
+
+	require.NoError(t, plugin.writeResults())
+
+	content, err := os.ReadFile(plugin.Config.ResultFile)
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", string(content))
+
+	// nothing configured, nothing written
+	assert.NoError(t, (&Plugin{}).writeResults())
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// traceTransport logs every request and response of the bot, whatever the
+// log level, since tracing is asked for explicitly. The logger hides the
+// token, which is part of every path.
+type traceTransport struct {
+	next   http.RoundTripper
+	logger *slog.Logger
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// uploads are held in memory while tracing to log their size, and a
+	// transport must not modify the request, so the clone gets the copy
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+	}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(ctx)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		attrs = append(attrs, traceBody(req.Header.Get("Content-Type"), body)...)
+	}
+	t.log(ctx, "http request", attrs...)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.log(ctx, "http error", slog.Any("error", err))
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.log(ctx, "http response",
+		slog.Int("status", resp.StatusCode),
+		slog.String("body", string(body)),
+	)
+
+	return resp, nil
+}
+
+// log hands the record to the handler directly, which skips the level check
+// of the logger.
+func (t *traceTransport) log(ctx context.Context, msg string, attrs ...slog.Attr) {
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)
+	r.AddAttrs(attrs...)
+	_ = t.logger.Handler().Handle(ctx, r)
+}
+
+// traceBody returns the form fields of a request and, for uploads, the
+// name and size of every file instead of its contents.
+func traceBody(contentType string, body []byte) []slog.Attr {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			break
+		}
+		return []slog.Attr{traceFields(values)}
+	case strings.HasPrefix(mediaType, "multipart/"):
+		values := url.Values{}
+		var files []any
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+
+			if len(part.FileName()) == 0 {
+				value, _ := io.ReadAll(part)
+				values.Add(part.FormName(), string(value))
+				continue
+			}
+
+			size, _ := io.Copy(io.Discard, part)
+			files = append(files, slog.Group(part.FormName(),
+				slog.String("name", part.FileName()),
+				slog.Int64("size", size),
+			))
+		}
+		return []slog.Attr{traceFields(values), slog.Group("files", files...)}
+	}
+
+	return []slog.Attr{slog.Int("size", len(body))}
+}
+
+// traceFields groups the form fields of a request.
+func traceFields(values url.Values) slog.Attr {
+	fields := make([]any, 0, len(values))
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		fields = append(fields, slog.String(name, values.Get(name)))
+	}
+
+	return slog.Group("fields", fields...)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceHTTP(t *testing.T) {
+	const token = "123456:ABC-DEF"
+	server := newBotServer(t, map[string]string{
+		"getMe":        botResult(testBot),
+		"sendMessage":  botResult(`{"message_id": 1, "chat": {"id": 1234567890}}`),
+		"sendDocument": botResult(`{"message_id": 2, "chat": {"id": 1234567890}}`),
+	})
+
+	info, err := os.Stat("tests/gophercolor.png")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	plugin := Plugin{
+		Config: Config{
+			Token:     token,
+			To:        []string{"1234567890"},
+			Document:  []string{"tests/gophercolor.png"},
+			APIURL:    server.URL,
+			LogFormat: logFormatJSON,
+			TraceHTTP: true,
+		},
+		logOutput: &buf,
+	}
+
+	require.NoError(t, plugin.Exec(t.Context()))
+	assert.NotContains(t, buf.String(), token)
+
+	entries := logEntries(t, &buf)
+	var requests, responses []map[string]any
+	for _, entry := range entries {
+		switch entry["msg"] {
+		case "http request":
+			requests = append(requests, entry)
+		case "http response":
+			responses = append(responses, entry)
+		}
+	}
+	// getMe, the built-in message and the document
+	require.Len(t, requests, 3)
+	require.Len(t, responses, 3)
+
+	assert.Equal(t, "POST", requests[0]["method"])
+	assert.Equal(t, "/bot<token>/getMe", requests[0]["path"])
+
+	assert.Equal(t, "/bot<token>/sendDocument", requests[2]["path"])
+	assert.Equal(t, map[string]any{"chat_id": "1234567890"}, requests[2]["fields"])
+	assert.Equal(t, map[string]any{
+		"document": map[string]any{"name": "gophercolor.png", "size": float64(info.Size())},
+	}, requests[2]["files"])
+
+	assert.InDelta(t, 200, responses[2]["status"], 0)
+	assert.JSONEq(t, botResult(`{"message_id": 2, "chat": {"id": 1234567890}}`), responses[2]["body"].(string))
+}
+
+func TestTraceHTTPForm(t *testing.T) {
+	server := newBotServer(t, map[string]string{
+		"getMe":       botResult(testBot),
+		"sendMessage": botError(400, "Bad Request: can't parse entities"),
+	})
+
+	var buf bytes.Buffer
+	plugin := Plugin{
+		Config: Config{
+			Token:     "123456:ABC",
+			To:        []string{"1234567890"},
+			Message:   "*unclosed",
+			Format:    formatMarkdown,
+			APIURL:    server.URL,
+			LogFormat: logFormatJSON,
+			TraceHTTP: true,
+		},
+		logOutput: &buf,
+	}
+
+	err := plugin.Exec(t.Context())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't parse entities")
+
+	var request, response map[string]any
+	for _, entry := range logEntries(t, &buf) {
+		if entry["msg"] == "http request" && entry["path"] == "/bot<token>/sendMessage" {
+			request = entry
+		}
+		if entry["msg"] == "http response" && entry["status"] == float64(200) {
+			response = entry
+		}
+	}
+	require.NotNil(t, request)
+	require.NotNil(t, response)
+
+	fields := request["fields"].(map[string]any)
+	assert.Equal(t, "*unclosed", fields["text"])
+	assert.Equal(t, formatMarkdown, fields["parse_mode"])
+	assert.Contains(t, response["body"], "can't parse entities")
+}
+
+func TestTraceHTTPLogLevel(t *testing.T) {
+	server := newBotServer(t, map[string]string{
+		"getMe":       botResult(testBot),
+		"sendMessage": botResult(`{"message_id": 1, "chat": {"id": 1234567890}}`),
+	})
+
+	var buf bytes.Buffer
+	plugin := Plugin{
+		Config: Config{
+			Token:     "123456:ABC",
+			To:        []string{"1234567890"},
+			Message:   "hello",
+			APIURL:    server.URL,
+			LogFormat: logFormatJSON,
+			LogLevel:  "error",
+			TraceHTTP: true,
+		},
+		logOutput: &buf,
+	}
+	require.NoError(t, plugin.Exec(t.Context()))
+
+	// the trace ignores the log level, the other info logs do not
+	var msgs []any
+	for _, entry := range logEntries(t, &buf) {
+		msgs = append(msgs, entry["msg"])
+	}
+	assert.Equal(t, []any{"http request", "http response", "http request", "http response"}, msgs)
+}
+
+func TestTraceTransportKeepsRequest(t *testing.T) {
+	var sent *http.Request
+	transport := &traceTransport{
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			sent = req
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, "text=hello", string(body))
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+		}),
+		logger: slog.New(slog.DiscardHandler),
+	}
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPost, "https://api.telegram.org/bot/sendMessage",
+		strings.NewReader("text=hello"))
+	require.NoError(t, err)
+	body := req.Body
+
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.NotSame(t, req, sent)
+	assert.Equal(t, body, req.Body)
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
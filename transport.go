@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// tlsVersions maps the tls_min_version setting onto the TLS versions.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// newHTTPClient returns the HTTP client of the bot. Every request, file
+// uploads included, goes through the configured proxy, or through the proxy
+// of the environment (HTTPS_PROXY, HTTP_PROXY and NO_PROXY) when none is set,
+// uses the configured TLS settings and fails after the request timeout.
+func (p *Plugin) newHTTPClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if p.customTLS() {
+		tlsConfig, err := p.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	// socks5 predates the proxy setting
+	var proxy string
+	setString(&proxy, p.Config.Proxy, p.Config.Socks5)
+	if len(proxy) > 0 {
+		proxyURL, err := parseProxyURL(proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if p.Config.TraceHTTP {
+		if err := p.initLogger(); err != nil {
+			return nil, err
+		}
+		return &http.Client{
+			Transport: &traceTransport{next: transport, logger: p.logger},
+			Timeout:   p.Config.Timeout,
+		}, nil
+	}
+
+	return &http.Client{Transport: transport, Timeout: p.Config.Timeout}, nil
+}
+
+// contextClient binds the requests the bot makes without a context, like
+// getMe when it starts, to the context of the run.
+type contextClient struct {
+	client *http.Client
+	ctx    context.Context
+}
+
+func (c contextClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Context() == context.Background() {
+		req = req.WithContext(c.ctx)
+	}
+
+	return c.client.Do(req)
+}
+
+// customTLS reports whether any TLS setting is set, otherwise the client
+// keeps the TLS configuration of the default transport.
+func (p *Plugin) customTLS() bool {
+	return len(p.Config.CACert) > 0 || len(p.Config.ClientCert) > 0 ||
+		len(p.Config.ClientKey) > 0 || len(p.Config.TLSMinVersion) > 0
+}
+
+// tlsConfig returns the TLS configuration for the CA bundle, the client
+// certificate and the minimum TLS version.
+func (p *Plugin) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if len(p.Config.TLSMinVersion) > 0 {
+		version, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(p.Config.TLSMinVersion), "tls")]
+		if !ok {
+			return nil, fmt.Errorf(
+				"unsupported TLS version '%s', use 1.0, 1.1, 1.2 or 1.3",
+				p.Config.TLSMinVersion,
+			)
+		}
+		cfg.MinVersion = version
+	}
+
+	if len(p.Config.CACert) > 0 {
+		ca, err := readPEM(p.Config.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificate: %w", err)
+		}
+
+		// the bundle adds to the system roots, so the public API still works
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("no certificate found in CA certificate")
+		}
+		cfg.RootCAs = pool
+	}
+
+	if len(p.Config.ClientCert) > 0 || len(p.Config.ClientKey) > 0 {
+		if len(p.Config.ClientCert) == 0 || len(p.Config.ClientKey) == 0 {
+			return nil, errors.New("client certificate and client key must be set together")
+		}
+
+		cert, err := readPEM(p.Config.ClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client certificate: %w", err)
+		}
+		key, err := readPEM(p.Config.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client key: %w", err)
+		}
+
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+
+	return cfg, nil
+}
+
+// readPEM returns a PEM value as is and reads the file of any other value.
+func readPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN ") {
+		return []byte(value), nil
+	}
+
+	return os.ReadFile(value)
+}
+
+// parseProxyURL parses an http, https or socks5 proxy URL with optional
+// credentials. Errors never include the password.
+func parseProxyURL(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		// url.Error repeats the URL and with it the password
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("unable to parse proxy URL: %w", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf(
+			"unsupported proxy URL '%s', use an http, https or socks5 URL",
+			proxyURL.Redacted(),
+		)
+	}
+
+	if len(proxyURL.Host) == 0 {
+		return nil, fmt.Errorf("missing host in proxy URL '%s'", proxyURL.Redacted())
+	}
+
+	return proxyURL, nil
+}
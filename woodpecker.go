@@ -0,0 +1,50 @@
+package main
+
+import "strconv"
+
+// loadWoodpeckerEnv fills the repository, commit and build information from
+// the Woodpecker CI pipeline variables.
+func (p *Plugin) loadWoodpeckerEnv(getenv func(string) string) {
+	setString(&p.Repo.FullName, getenv("CI_REPO"))
+	setString(&p.Repo.Namespace, getenv("CI_REPO_OWNER"))
+	setString(&p.Repo.Name, getenv("CI_REPO_NAME"))
+
+	setString(&p.Commit.Sha, getenv("CI_COMMIT_SHA"))
+	setString(&p.Commit.Ref, getenv("CI_COMMIT_REF"))
+	setString(&p.Commit.Branch, getenv("CI_COMMIT_TARGET_BRANCH"), getenv("CI_COMMIT_BRANCH"))
+	setString(&p.Commit.Message, getenv("CI_COMMIT_MESSAGE"))
+	setString(&p.Commit.Author, getenv("CI_COMMIT_AUTHOR"))
+	setString(&p.Commit.Email, getenv("CI_COMMIT_AUTHOR_EMAIL"))
+	setString(&p.Commit.Avatar, getenv("CI_COMMIT_AUTHOR_AVATAR"))
+	// older releases name the links *_LINK
+	setString(&p.Commit.Link, getenv("CI_PIPELINE_FORGE_URL"), getenv("CI_COMMIT_URL"), getenv("CI_COMMIT_LINK"))
+
+	if number, err := strconv.Atoi(getenv("CI_PIPELINE_NUMBER")); err == nil {
+		p.Build.Number = number
+	}
+	setString(&p.Build.Link, getenv("CI_PIPELINE_URL"), getenv("CI_PIPELINE_LINK"))
+	setString(&p.Build.Status, getenv("CI_PIPELINE_STATUS"))
+	setString(&p.Build.Tag, getenv("CI_COMMIT_TAG"))
+	setString(&p.Build.PR, getenv("CI_COMMIT_PULL_REQUEST"))
+	setString(&p.Build.DeployTo, getenv("CI_PIPELINE_DEPLOY_TARGET"))
+	if started, err := strconv.ParseInt(getenv("CI_PIPELINE_STARTED"), 10, 64); err == nil {
+		p.Build.Started = started
+	}
+	if finished, err := strconv.ParseInt(getenv("CI_PIPELINE_FINISHED"), 10, 64); err == nil {
+		p.Build.Finished = finished
+	}
+
+	switch event := getenv("CI_PIPELINE_EVENT"); event {
+	case "":
+	case "pull_request", "pull_request_closed":
+		p.Build.Event = "pull_request"
+	case "tag", "release":
+		p.Build.Event = "tag"
+	case "deployment":
+		p.Build.Event = "promote"
+	case "manual":
+		p.Build.Event = "push"
+	default:
+		p.Build.Event = event
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWoodpeckerEnv(t *testing.T) {
+	plugin := Plugin{
+		Commit: Commit{Branch: "master"},
+		Build:  Build{Event: "push", Status: "success"},
+		Config: Config{Provider: providerWoodpecker},
+	}
+
+	plugin.loadWoodpeckerEnv(mapEnv(map[string]string{
+		"CI":                      "woodpecker",
+		"CI_REPO":                 "appleboy/go-hello",
+		"CI_REPO_OWNER":           "appleboy",
+		"CI_REPO_NAME":            "go-hello",
+		"CI_COMMIT_SHA":           "e7c4f0a63ceeb42a39ac7806f7b51f3f0d204fd2",
+		"CI_COMMIT_REF":           "refs/pull/8/head",
+		"CI_COMMIT_BRANCH":        "feature/topics",
+		"CI_COMMIT_TARGET_BRANCH": "main",
+		"CI_COMMIT_PULL_REQUEST":  "8",
+		"CI_COMMIT_MESSAGE":       "feat: support forum topics",
+		"CI_COMMIT_AUTHOR":        "appleboy",
+		"CI_COMMIT_AUTHOR_EMAIL":  "appleboy.tw@gmail.com",
+		"CI_PIPELINE_NUMBER":      "21",
+		"CI_PIPELINE_EVENT":       "pull_request",
+		"CI_PIPELINE_URL":         "https://ci.example.com/repos/3/pipeline/21",
+		"CI_PIPELINE_FORGE_URL":   "https://git.example.com/appleboy/go-hello/pulls/8",
+		"CI_PIPELINE_STATUS":      "failure",
+		"CI_PIPELINE_STARTED":     "1700000000",
+	}))
+
+	assert.Equal(t, "appleboy/go-hello", plugin.Repo.FullName)
+	assert.Equal(t, "main", plugin.Commit.Branch)
+	assert.Equal(t, "pull_request", plugin.Build.Event)
+	assert.Equal(t, "8", plugin.Build.PR)
+	assert.Equal(t, 21, plugin.Build.Number)
+	assert.Equal(t, int64(1700000000), plugin.Build.Started)
+	assert.Equal(
+		t,
+		[]string{
+			"❌ Pipeline #21 of `appleboy/go-hello` failure.\n\n🔀 PR #8 by appleboy into `main`:\n``` feat: support forum topics ```\n\n🔗 https://git.example.com/appleboy/go-hello/pulls/8\n🌐 https://ci.example.com/repos/3/pipeline/21",
+		},
+		plugin.Message(),
+	)
+}
+
+func TestLoadWoodpeckerEnvEvents(t *testing.T) {
+	tests := map[string]string{
+		"push":       "push",
+		"manual":     "push",
+		"tag":        "tag",
+		"release":    "tag",
+		"deployment": "promote",
+		"cron":       "cron",
+	}
+
+	for event, want := range tests {
+		plugin := Plugin{}
+		plugin.loadWoodpeckerEnv(mapEnv(map[string]string{"CI_PIPELINE_EVENT": event}))
+		assert.Equal(t, want, plugin.Build.Event, event)
+	}
+
+	plugin := Plugin{Build: Build{Event: "push"}}
+	plugin.loadWoodpeckerEnv(mapEnv(nil))
+	assert.Equal(t, "push", plugin.Build.Event)
+}